@@ -0,0 +1,587 @@
+package httpx
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Digest algorithms
+// See: https://www.rfc-editor.org/rfc/rfc7616#section-3.3
+const (
+	DigestMD5           = "MD5"
+	DigestMD5Sess       = "MD5-sess"
+	DigestSHA256        = "SHA-256"
+	DigestSHA256Sess    = "SHA-256-sess"
+	DigestSHA512256     = "SHA-512-256"
+	DigestSHA512256Sess = "SHA-512-256-sess"
+)
+
+const defaultNonceTTL = 5 * time.Minute
+
+// maxDigestNonces is the maximum number of used nonces whose nonce counts are tracked to detect replays.
+const maxDigestNonces = 4096
+
+var errInvalidDigest = errors.New("invalid digest credentials")
+
+// authorization returns the credentials of the "Authorization" header if its scheme is scheme.
+func (r *Request) authorization(scheme string) (string, bool) {
+	auth := r.Request.Header.Get(HeaderAuthorization)
+	if len(auth) <= len(scheme) || auth[len(scheme)] != ' ' || !strings.EqualFold(auth[:len(scheme)], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len(scheme)+1:]), true
+}
+
+// BasicAuthUTF8 returns the username and password provided in the request's "Authorization" header
+// with the Basic scheme. Unlike http.Request.BasicAuth, credentials are decoded as UTF-8 as per RFC 7617,
+// falling back to ISO-8859-1 for legacy clients if they are not valid UTF-8.
+// Credentials containing control characters are rejected.
+func (r *Request) BasicAuthUTF8() (username, password string, ok bool) {
+	auth, ok := r.authorization("Basic")
+	if !ok {
+		return "", "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return "", "", false
+	}
+	credentials := string(b)
+	if !utf8.ValidString(credentials) {
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		credentials = string(runes)
+	}
+	for _, c := range credentials {
+		if c < 0x20 || c == 0x7f {
+			return "", "", false
+		}
+	}
+	username, password, ok = strings.Cut(credentials, ":")
+	if !ok {
+		return "", "", false
+	}
+	return username, password, true
+}
+
+// BearerToken returns the token provided in the request's "Authorization" header with the Bearer scheme.
+// See: https://www.rfc-editor.org/rfc/rfc6750#section-2.1
+func (r *Request) BearerToken() (string, bool) {
+	token, ok := r.authorization("Bearer")
+	if !ok || !isToken68(token) {
+		return "", false
+	}
+	return token, true
+}
+
+func isToken68(s string) bool {
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~+/", c) >= 0) {
+			break
+		}
+	}
+	if i == 0 {
+		return false
+	}
+	for ; i < len(s); i++ {
+		if s[i] != '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// DigestCredentials are the parameters of an "Authorization" header with the Digest scheme.
+// See: https://www.rfc-editor.org/rfc/rfc7616#section-3.4
+type DigestCredentials struct {
+	Username  string
+	Realm     string
+	URI       string
+	Algorithm string
+	Nonce     string
+	CNonce    string
+	NC        string
+	QOP       string
+	Response  string
+	Opaque    string
+	UserHash  bool
+}
+
+// DigestCredentials returns the credentials provided in the request's "Authorization" header with the Digest scheme.
+func (r *Request) DigestCredentials() (*DigestCredentials, bool) {
+	auth, ok := r.authorization("Digest")
+	if !ok {
+		return nil, false
+	}
+	credentials, err := ParseDigestCredentials(auth)
+	return credentials, err == nil
+}
+
+// ParseDigestCredentials parses the parameters of an "Authorization" header with the Digest scheme,
+// i.e. the header value without the leading "Digest".
+func ParseDigestCredentials(s string) (*DigestCredentials, error) {
+	params, err := parseAuthParams(s)
+	if err != nil {
+		return nil, err
+	}
+	c := &DigestCredentials{
+		Username:  params["username"],
+		Realm:     params["realm"],
+		URI:       params["uri"],
+		Algorithm: params["algorithm"],
+		Nonce:     params["nonce"],
+		CNonce:    params["cnonce"],
+		NC:        params["nc"],
+		QOP:       params["qop"],
+		Response:  params["response"],
+		Opaque:    params["opaque"],
+		UserHash:  strings.EqualFold(params["userhash"], "true"),
+	}
+	if extended, ok := params["username*"]; ok {
+		if c.Username != "" {
+			return nil, errInvalidDigest
+		}
+		if c.Username, err = decodeExtValue(extended); err != nil {
+			return nil, err
+		}
+	}
+	if c.Algorithm == "" {
+		c.Algorithm = DigestMD5
+	}
+	if c.Username == "" || c.Nonce == "" || c.URI == "" || c.Response == "" {
+		return nil, errInvalidDigest
+	}
+	return c, nil
+}
+
+// parseAuthParams parses a comma separated list of auth-params, i.e. name=token or name="quoted string".
+// Parameter names are returned in lower case.
+func parseAuthParams(s string) (map[string]string, error) {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params, nil
+		}
+		i := strings.IndexByte(s, '=')
+		if i <= 0 {
+			return nil, errInvalidDigest
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, errInvalidDigest // unterminated quoted string
+			}
+			value, s = b.String(), s[j+1:]
+		} else {
+			j := strings.IndexByte(s, ',')
+			if j < 0 {
+				j = len(s)
+			}
+			value, s = strings.TrimSpace(s[:j]), s[j:]
+		}
+		s = strings.TrimLeft(s, " \t")
+		if s != "" && s[0] != ',' {
+			return nil, errInvalidDigest
+		}
+		params[name] = value
+	}
+}
+
+// decodeExtValue decodes an RFC 8187 ext-value in the UTF-8 charset, e.g. "UTF-8'en'%C3%A4".
+func decodeExtValue(s string) (string, error) {
+	parts := strings.SplitN(s, "'", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "UTF-8") {
+		return "", errInvalidDigest
+	}
+	value, err := url.PathUnescape(parts[2])
+	if err != nil || !utf8.ValidString(value) {
+		return "", errInvalidDigest
+	}
+	return value, nil
+}
+
+// quote returns s as a quoted-string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// BasicChallenge returns a "WWW-Authenticate" challenge for the Basic scheme,
+// which asks clients to encode credentials in UTF-8.
+func BasicChallenge(realm string) string {
+	return "Basic realm=" + quote(realm) + `, charset="UTF-8"`
+}
+
+// BearerChallenge returns a "WWW-Authenticate" challenge for the Bearer scheme.
+// The error code (e.g. "invalid_token") and its description are omitted if empty.
+// See: https://www.rfc-editor.org/rfc/rfc6750#section-3
+func BearerChallenge(realm, code, description string) string {
+	challenge := "Bearer realm=" + quote(realm)
+	if code != "" {
+		challenge += ", error=" + quote(code)
+	}
+	if description != "" {
+		challenge += ", error_description=" + quote(description)
+	}
+	return challenge
+}
+
+// DigestAuth verifies credentials of the Digest scheme and manages the nonces issued to clients.
+// See: https://www.rfc-editor.org/rfc/rfc7616
+type DigestAuth struct {
+	// Realm is the protection space of the credentials.
+	Realm string
+	// Algorithms are the algorithms offered to clients in order of preference. Defaults to SHA-256 and MD5.
+	Algorithms []string
+	// QOP are the qualities of protection offered to clients. Defaults to "auth".
+	// With "auth-int", the request body is buffered with Request.BufferBody to compute its digest.
+	QOP []string
+	// NonceTTL is the duration after which a nonce is stale. Defaults to 5 minutes.
+	NonceTTL time.Duration
+	// Password returns the password of username, or false if there is no such user.
+	Password func(username string) (string, bool)
+	// Usernames returns every username, which is required to support hashed usernames.
+	// Clients are not offered to hash usernames if Usernames is nil.
+	Usernames func() []string
+
+	once sync.Once
+	// key signs the nonces, which carry the time they were issued at, so that issuing them requires no state.
+	key    []byte
+	opaque string
+
+	mu sync.Mutex
+	// nonces are the nonce counts of the used nonces, in order of first use.
+	nonces map[string]*digestNonce
+	order  []string
+	// evicted is the latest time a nonce was issued at whose nonce count was evicted,
+	// so that replays of nonces issued up to then cannot be detected.
+	evicted time.Time
+}
+
+type digestNonce struct {
+	issued time.Time
+	nc     uint64
+}
+
+// NewDigestAuth creates a new instance of DigestAuth with the default algorithms, quality of protection and nonce TTL.
+func NewDigestAuth(realm string, password func(username string) (string, bool)) *DigestAuth {
+	d := &DigestAuth{Realm: realm, Password: password}
+	d.init()
+	return d
+}
+
+// init sets the defaults of the fields left empty and the internal state of d, once.
+// It allows DigestAuth to be created as a struct literal as well.
+func (d *DigestAuth) init() {
+	d.once.Do(func() {
+		if len(d.Algorithms) == 0 {
+			d.Algorithms = []string{DigestSHA256, DigestMD5}
+		}
+		if len(d.QOP) == 0 {
+			d.QOP = []string{"auth"}
+		}
+		if d.NonceTTL <= 0 {
+			d.NonceTTL = defaultNonceTTL
+		}
+		d.key = make([]byte, 32)
+		if _, err := rand.Read(d.key); err != nil {
+			panic(err)
+		}
+		d.nonces = make(map[string]*digestNonce)
+		d.opaque = randomToken()
+	})
+}
+
+// Challenges returns a "WWW-Authenticate" challenge with a new nonce for each algorithm of d.
+// If stale is true, clients are told that their nonce expired so that they retry with the new nonce
+// without prompting users for credentials again.
+func (d *DigestAuth) Challenges(stale bool) []string {
+	d.init()
+	nonce := d.issueNonce()
+	challenges := make([]string, 0, len(d.Algorithms))
+	for _, algorithm := range d.Algorithms {
+		challenge := "Digest realm=" + quote(d.Realm) +
+			", qop=" + quote(strings.Join(d.QOP, ", ")) +
+			", algorithm=" + algorithm +
+			", nonce=" + quote(nonce) +
+			", opaque=" + quote(d.opaque) +
+			", charset=UTF-8"
+		if d.Usernames != nil {
+			challenge += ", userhash=true"
+		}
+		if stale {
+			challenge += ", stale=true"
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges
+}
+
+// issueNonce returns a new nonce consisting of the time it is issued at, random bytes and their signature.
+func (d *DigestAuth) issueNonce() string {
+	b := make([]byte, 16, 16+sha256.Size)
+	binary.BigEndian.PutUint64(b, uint64(time.Now().UnixNano()))
+	if _, err := rand.Read(b[8:]); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(append(b, d.signNonce(b)...))
+}
+
+func (d *DigestAuth) signNonce(b []byte) []byte {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write(b)
+	return mac.Sum(nil)
+}
+
+// parseNonce returns the time nonce was issued at, or false if nonce was not issued by d.
+func (d *DigestAuth) parseNonce(nonce string) (time.Time, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != 16+sha256.Size || !hmac.Equal(b[16:], d.signNonce(b[:16])) {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))), true
+}
+
+// useNonce reports whether nonce is valid and its nonce count nc was not used before.
+func (d *DigestAuth) useNonce(nonce string, nc uint64) (valid, stale bool) {
+	issued, ok := d.parseNonce(nonce)
+	now := time.Now()
+	if !ok || now.After(issued.Add(d.NonceTTL)) {
+		return false, true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, ok := d.nonces[nonce]
+	if !ok {
+		if !issued.After(d.evicted) {
+			// the nonce may have been used before its nonce count was evicted
+			return false, true
+		}
+		d.evictNonces(now)
+		n = &digestNonce{issued: issued}
+		d.nonces[nonce] = n
+		d.order = append(d.order, nonce)
+	}
+	if nc <= n.nc {
+		return false, false // replayed request
+	}
+	n.nc = nc
+	return true, false
+}
+
+// evictNonces removes the nonce counts of the expired nonces, and of the oldest used nonces
+// to make room for a new one if there are maxDigestNonces of them.
+func (d *DigestAuth) evictNonces(now time.Time) {
+	for len(d.order) > 0 {
+		n := d.nonces[d.order[0]]
+		if len(d.nonces) < maxDigestNonces && !now.After(n.issued.Add(d.NonceTTL)) {
+			break
+		}
+		if n.issued.After(d.evicted) {
+			d.evicted = n.issued
+		}
+		delete(d.nonces, d.order[0])
+		d.order = d.order[1:]
+	}
+}
+
+// Authenticate verifies the Digest credentials of req and returns the username if they are valid.
+// If stale is true, the credentials were computed with an unknown or expired nonce
+// and the client should be challenged again with Challenges(true).
+func (d *DigestAuth) Authenticate(req *Request) (username string, stale bool, ok bool) {
+	d.init()
+	c, ok := req.DigestCredentials()
+	if !ok || c.Realm != d.Realm || c.Opaque != d.opaque || !containsFold(d.QOP, c.QOP) || !containsFold(d.Algorithms, c.Algorithm) {
+		return "", false, false
+	}
+	if c.URI != req.RequestURI && c.URI != req.URL.RequestURI() {
+		return "", false, false
+	}
+	newHash := digestHash(c.Algorithm)
+	if newHash == nil {
+		return "", false, false
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	username = c.Username
+	if c.UserHash {
+		if username, ok = d.lookupUserHash(c.Username, h); !ok {
+			return "", false, false
+		}
+	}
+	password, ok := d.Password(username)
+	if !ok {
+		return "", false, false
+	}
+
+	ha1 := h(username + ":" + d.Realm + ":" + password)
+	if strings.HasSuffix(strings.ToLower(c.Algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + c.Nonce + ":" + c.CNonce)
+	}
+	ha2 := h(req.Method + ":" + c.URI)
+	if strings.EqualFold(c.QOP, "auth-int") {
+		body, err := req.BodyBytes()
+		if err != nil {
+			return "", false, false
+		}
+		ha2 = h(req.Method + ":" + c.URI + ":" + h(string(body)))
+	}
+	expected := h(ha1 + ":" + c.Nonce + ":" + c.NC + ":" + c.CNonce + ":" + c.QOP + ":" + ha2)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(c.Response))) != 1 {
+		return "", false, false
+	}
+
+	// the nonce is only consumed by valid credentials, so that forged requests cannot invalidate it
+	nc, err := strconv.ParseUint(c.NC, 16, 64)
+	if err != nil {
+		return "", false, false
+	}
+	if valid, stale := d.useNonce(c.Nonce, nc); !valid {
+		return "", stale, false
+	}
+	return username, false, true
+}
+
+func (d *DigestAuth) lookupUserHash(userhash string, h func(string) string) (string, bool) {
+	if d.Usernames == nil {
+		return "", false
+	}
+	for _, username := range d.Usernames() {
+		if h(username+":"+d.Realm) == strings.ToLower(userhash) {
+			return username, true
+		}
+	}
+	return "", false
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToLower(algorithm), "-sess")) {
+	case DigestMD5:
+		return md5.New
+	case DigestSHA256:
+		return sha256.New
+	case DigestSHA512256:
+		return sha512.New512_256
+	}
+	return nil
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AuthConfig configures the Auth middleware. At least one scheme must be enabled.
+type AuthConfig struct {
+	// Realm is the protection space announced in Basic and Bearer challenges.
+	Realm string
+	// Basic validates the credentials of the Basic scheme, which is disabled if Basic is nil.
+	Basic func(req *Request, username, password string) (bool, error)
+	// Bearer validates the tokens of the Bearer scheme, which is disabled if Bearer is nil.
+	Bearer func(req *Request, token string) (bool, error)
+	// Digest verifies the credentials of the Digest scheme, which is disabled if Digest is nil.
+	Digest *DigestAuth
+}
+
+// Auth returns a middleware that authenticates requests with the schemes enabled in config.
+// Requests without valid credentials are rejected with ErrUnauthorized through HTTPErrorHandler,
+// with a "WWW-Authenticate" challenge for each enabled scheme.
+// Errors returned by the validation functions are returned as is.
+func Auth(config AuthConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HandlerFunc(func(req *Request, res *Responder) error {
+			var stale, invalidToken bool
+			if config.Basic != nil {
+				if username, password, ok := req.BasicAuthUTF8(); ok {
+					valid, err := config.Basic(req, username, password)
+					if err != nil {
+						return err
+					} else if valid {
+						return H(next)(req, res)
+					}
+				}
+			}
+			if config.Bearer != nil {
+				if token, ok := req.BearerToken(); ok {
+					valid, err := config.Bearer(req, token)
+					if err != nil {
+						return err
+					} else if valid {
+						return H(next)(req, res)
+					}
+					invalidToken = true
+				}
+			}
+			if config.Digest != nil {
+				var ok bool
+				if _, stale, ok = config.Digest.Authenticate(req); ok {
+					return H(next)(req, res)
+				}
+			}
+
+			header := res.Header()
+			if config.Basic != nil {
+				header.Add(HeaderWWWAuthenticate, BasicChallenge(config.Realm))
+			}
+			if config.Bearer != nil {
+				code := ""
+				if invalidToken {
+					code = "invalid_token"
+				}
+				header.Add(HeaderWWWAuthenticate, BearerChallenge(config.Realm, code, ""))
+			}
+			if config.Digest != nil {
+				for _, challenge := range config.Digest.Challenges(stale) {
+					header.Add(HeaderWWWAuthenticate, challenge)
+				}
+			}
+			return ErrUnauthorized
+		})
+	}
+}
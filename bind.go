@@ -6,10 +6,15 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // BindUnmarshaler is the interface used to wrap the UnmarshalParam method.
@@ -20,11 +25,56 @@ type BindUnmarshaler interface {
 	UnmarshalParam(param string) error
 }
 
-type DefaultRequestBinder struct{}
+var (
+	fileHeaderType   = reflect.TypeOf((*multipart.FileHeader)(nil))
+	uploadedFileType = reflect.TypeOf(UploadedFile{})
+	timeType         = reflect.TypeOf(time.Time{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+)
+
+const (
+	defaultMaxParamDepth    = 10
+	defaultMaxParamIndex    = 1000
+	defaultMaxParamElements = 10000
+)
+
+// DefaultRequestBinder is the default implementation of RequestBinder.
+type DefaultRequestBinder struct {
+	// MaxParamDepth is the maximum nesting depth of query and form param keys in bracket or dot notation,
+	// e.g. "a[b][c]" has a depth of 3. Defaults to 10.
+	MaxParamDepth int
+	// MaxParamIndex is the maximum slice index of query and form param keys, e.g. "items[99]". Defaults to 1000.
+	MaxParamIndex int
+	// MaxParamElements is the maximum total number of slice elements allocated for the indices of query and form
+	// param keys of a source, e.g. 100 for "a[99]" even if "a[0]" to "a[98]" are absent. Defaults to 10000.
+	MaxParamElements int
+	// ValidateTags enables validating the bound object according to its "validate" tags at the end of Bind.
+	// See: ValidateStruct
+	ValidateTags bool
+
+	// DisallowUnknownFields rejects JSON bodies with object keys that do not match any field of the bound object.
+	DisallowUnknownFields bool
+	// UseNumber decodes JSON numbers into interface values as json.Number instead of float64.
+	UseNumber bool
+	// MaxBodyBytes is the maximum number of bytes of bodies other than forms, which applies in addition to
+	// the BodyLimits of the request. Zero means no additional limit.
+	MaxBodyBytes int64
+	// MaxJSONDepth is the maximum nesting depth of JSON arrays and objects. Zero means unlimited.
+	MaxJSONDepth int
+	// RejectTrailingData rejects JSON bodies with data after the first JSON value, which is ignored otherwise.
+	RejectTrailingData bool
+
+	// CollectErrors enables collecting the errors of converting values of every field into BindingErrors,
+	// instead of returning the first error. Bind collects the errors across all sources.
+	CollectErrors bool
+}
 
 // BindBody binds request body contents to bindable object.
-// NB: then binding forms take note that this implementation uses standard library form parsing
-// which parses form data from BOTH URL and BODY if content type is not MIMEMultipartForm
+// Bodies compressed with gzip or deflate are decompressed according to the "Content-Encoding" header.
+// The body is decoded with the Decoder registered for the "Content-Type" header, see RegisterDecoder.
+// ErrUnsupportedMediaType is returned if there is no such decoder.
+// NB: forms are parsed with the standard library form parsing, but only the params of the BODY are bound,
+// whereas those of the URL are bound by BindQueryParams.
 // See non-MIMEMultipartForm: https://golang.org/pkg/net/http/#Request.ParseForm
 // See MIMEMultipartForm: https://golang.org/pkg/net/http/#Request.ParseMultipartForm
 func (b *DefaultRequestBinder) BindBody(req *Request, i any) (err error) {
@@ -32,63 +82,360 @@ func (b *DefaultRequestBinder) BindBody(req *Request, i any) (err error) {
 		return
 	}
 
-	ctype := req.Header.Get(HeaderContentType)
-	switch {
-	case strings.HasPrefix(ctype, MIMEApplicationJSON):
-		dec := json.NewDecoder(req.Body)
-		if err = dec.Decode(i); err != nil {
-			switch err.(type) {
-			case *HTTPError:
-				return err
-			default:
-				return WrapHTTPError(err, http.StatusBadRequest, err.Error())
-			}
-		}
-	case strings.HasPrefix(ctype, MIMEApplicationXML), strings.HasPrefix(ctype, MIMETextXML):
-		if err = xml.NewDecoder(req.Body).Decode(i); err != nil {
-			if ute, ok := err.(*xml.UnsupportedTypeError); ok {
-				return WrapHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error()))
-			} else if se, ok := err.(*xml.SyntaxError); ok {
-				return WrapHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error()))
-			}
-			return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+	if err = req.DecompressBody(); err != nil {
+		return err
+	}
+	req.rewindBody()
+	var body io.Reader = req.Body
+	if b.MaxBodyBytes > 0 {
+		body = &limitedBody{ReadCloser: io.NopCloser(body), limit: b.MaxBodyBytes}
+	}
+	decoder, ok := lookupDecoder(req.Header.Get(HeaderContentType))
+	if !ok {
+		return ErrUnsupportedMediaType
+	}
+	if d, ok := decoder.(builtinDecoder); ok {
+		return b.decode(d, req, body, i)
+	}
+	if err = decoder.Decode(req, body, i); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// decode decodes body with a built-in decoder according to the options of the binder.
+func (b *DefaultRequestBinder) decode(d builtinDecoder, req *Request, body io.Reader, i any) error {
+	switch d {
+	case jsonDecoder:
+		return b.decodeJSON(body, i)
+	case xmlDecoder:
+		return b.decodeXML(body, i)
+	case formDecoder:
+		return b.bindForm(req, i)
+	}
+	return ErrUnsupportedMediaType
+}
+
+// decodeXML decodes an XML body.
+func (b *DefaultRequestBinder) decodeXML(r io.Reader, i any) error {
+	if err := xml.NewDecoder(r).Decode(i); err != nil {
+		if errors.Is(err, ErrStatusRequestEntityTooLarge) {
+			return ErrStatusRequestEntityTooLarge
+		} else if ute, ok := err.(*xml.UnsupportedTypeError); ok {
+			return WrapHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error()))
+		} else if se, ok := err.(*xml.SyntaxError); ok {
+			return WrapHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error()))
 		}
-	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
-		params, err := req.FormParams()
-		if err != nil {
-			return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+		return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// bindForm binds a form body, including the files of a multipart form. Query params are not bound from the body.
+// The body is read with Request.FormParams, so the BodyLimits of the request apply rather than MaxBodyBytes.
+func (b *DefaultRequestBinder) bindForm(req *Request, i any) error {
+	params, err := req.postFormParams()
+	if err == ErrStatusRequestEntityTooLarge {
+		return err
+	} else if err != nil {
+		return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+	}
+	if err = b.bindData(i, params, "form"); err != nil {
+		return bindDataError(err)
+	}
+	if form := req.Request.MultipartForm; form != nil && len(form.File) > 0 {
+		if err = bindFiles(i, form.File); err != nil {
+			return err
 		}
-		if err = b.bindData(i, params, "form"); err != nil {
-			return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// decodeJSON decodes a JSON body according to the options of the binder.
+func (b *DefaultRequestBinder) decodeJSON(r io.Reader, i any) error {
+	if b.MaxJSONDepth > 0 {
+		r = &jsonDepthReader{r: r, max: b.MaxJSONDepth}
+	}
+	dec := json.NewDecoder(r)
+	if b.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if b.UseNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(i); err != nil {
+		return jsonError(err, dec.InputOffset())
+	}
+	if b.RejectTrailingData {
+		offset := dec.InputOffset()
+		if _, err := dec.Token(); err != io.EOF {
+			var he *HTTPError
+			if errors.As(err, &he) {
+				return he
+			}
+			e := NewBindingError("", nil, fmt.Sprintf("Trailing data error: offset=%v", offset), err).(*BindingError)
+			e.Offset = offset
+			return e
 		}
-	default:
-		return ErrUnsupportedMediaType
 	}
 	return nil
 }
 
+// jsonError converts an error of decoding a JSON body into an HTTPError or a BindingError
+// with the offset of the error in the body.
+func jsonError(err error, offset int64) error {
+	var he *HTTPError
+	var ute *json.UnmarshalTypeError
+	var se *json.SyntaxError
+	switch {
+	case errors.As(err, &he):
+		return he
+	case errors.As(err, &ute):
+		e := NewBindingError(ute.Field, []string{ute.Value}, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset), err).(*BindingError)
+		e.Offset = ute.Offset
+		return e
+	case errors.As(err, &se):
+		e := NewBindingError("", nil, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error()), err).(*BindingError)
+		e.Offset = se.Offset
+		return e
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// returned by json.Decoder.DisallowUnknownFields, without a dedicated type
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		e := NewBindingError(field, nil, fmt.Sprintf("Unknown field error: field=%v, offset=%v", field, offset), err).(*BindingError)
+		e.Offset = offset
+		return e
+	}
+	return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+}
+
+// jsonDepthReader returns an error once the nesting depth of the JSON read from it exceeds max.
+type jsonDepthReader struct {
+	r        io.Reader
+	max      int
+	depth    int
+	inString bool
+	escaped  bool
+	err      error
+}
+
+func (d *jsonDepthReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	n, err := d.r.Read(p)
+	for i, c := range p[:n] {
+		switch {
+		case d.inString:
+			if d.escaped {
+				d.escaped = false
+			} else if c == '\\' {
+				d.escaped = true
+			} else if c == '"' {
+				d.inString = false
+			}
+		case c == '"':
+			d.inString = true
+		case c == '{' || c == '[':
+			if d.depth++; d.depth > d.max {
+				d.err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("JSON exceeds maximum nesting depth of %d", d.max))
+				return i, d.err
+			}
+		case c == '}' || c == ']':
+			d.depth--
+		}
+	}
+	return n, err
+}
+
 // BindQueryParams binds query params to bindable object.
 func (b *DefaultRequestBinder) BindQueryParams(req *Request, i any) error {
 	if err := b.bindData(i, req.QueryParams(), "query"); err != nil {
-		return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+		return bindDataError(err)
 	}
 	return nil
 }
 
+// BindPathParams binds path params to bindable object.
+// Path params are extracted with PathParamExtractor for every field with a "param" tag.
+func (b *DefaultRequestBinder) BindPathParams(req *Request, i any) error {
+	if err := b.bindData(i, pathParams(req, reflect.TypeOf(i)), "param"); err != nil {
+		return bindDataError(err)
+	}
+	return nil
+}
+
+// pathParams returns the path params of the fields of typ with a "param" tag.
+func pathParams(req *Request, typ reflect.Type) map[string][]string {
+	params := make(map[string][]string)
+	for _, name := range tagNames(typ, "param") {
+		if value := req.Param(name); value != "" {
+			params[name] = []string{value}
+		}
+	}
+	return params
+}
+
+// BindHeaders binds request headers to bindable object.
+// Headers are matched case-insensitively for every field with a "header" tag,
+// and all values of a header repeated in multiple lines are bound to a slice field.
+func (b *DefaultRequestBinder) BindHeaders(req *Request, i any) error {
+	if err := b.bindData(i, headerParams(req, reflect.TypeOf(i)), "header"); err != nil {
+		return bindDataError(err)
+	}
+	return nil
+}
+
+// headerParams returns the request headers of the fields of typ with a "header" tag.
+func headerParams(req *Request, typ reflect.Type) map[string][]string {
+	headers := make(map[string][]string)
+	for _, name := range tagNames(typ, "header") {
+		if values := req.Header.Values(name); len(values) > 0 {
+			headers[name] = values
+		}
+	}
+	return headers
+}
+
+// BindCookies binds request cookies to bindable object.
+// Cookies are matched by name for every field with a "cookie" tag. Cookies of fields with the "signed" or
+// "encrypted" tag option, e.g. `cookie:"session,signed"`, are verified using Request.SignedCookie or
+// Request.EncryptedCookie, and a BindingError is returned if the verification fails.
+func (b *DefaultRequestBinder) BindCookies(req *Request, i any) error {
+	cookies, err := cookieParams(req, reflect.TypeOf(i))
+	if err != nil {
+		return err
+	}
+	if err = b.bindData(i, cookies, "cookie"); err != nil {
+		return bindDataError(err)
+	}
+	return nil
+}
+
+// cookieParams returns the request cookies of the fields of typ with a "cookie" tag,
+// and a BindingError if the verification of a signed or encrypted cookie fails.
+func cookieParams(req *Request, typ reflect.Type) (map[string][]string, error) {
+	cookies := make(map[string][]string)
+	for _, field := range taggedFields(typ, "cookie") {
+		name, opts := parseTag(field.Tag.Get("cookie"))
+		var cookie *http.Cookie
+		var err error
+		switch {
+		case opts.Contains("signed"):
+			cookie, err = req.SignedCookie(name)
+		case opts.Contains("encrypted"):
+			cookie, err = req.EncryptedCookie(name)
+		default:
+			for _, c := range req.Cookies() {
+				if c.Name == name {
+					cookies[name] = append(cookies[name], c.Value)
+				}
+			}
+			continue
+		}
+		if err == http.ErrNoCookie {
+			continue
+		} else if errors.Is(err, ErrInvalidCookie) {
+			return nil, NewBindingError(name, nil, "invalid cookie", err)
+		} else if err != nil {
+			return nil, err
+		}
+		cookies[name] = []string{cookie.Value}
+	}
+	return cookies, nil
+}
+
+// BindDefaults sets the fields of bindable object that have a "default" tag and a zero value, e.g. `default:"20"`,
+// including those of nested structs. Values of slice fields are separated by commas, e.g. `default:"a,b"`,
+// unless the type implements BindUnmarshaler or encoding.TextUnmarshaler.
+func (b *DefaultRequestBinder) BindDefaults(req *Request, i any) error {
+	val := reflect.ValueOf(i)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setDefaults(val.Elem())
+}
+
 // Bind implements the DefaultRequestBinder.Bind function.
-// Binding is done in following order: 1) request body; 2) query params. Each step COULD override previous
-// step bound values. For single source binding use their own methods BindBody, BindQueryParams.
+// Defaults are set with BindDefaults first, then binding is done in following order: 1) path params; 2) request body;
+// 3) query params; 4) headers; 5) cookies. Each step COULD override previous step bound values. For single source
+// binding use their own methods BindPathParams, BindBody, BindQueryParams, BindHeaders, BindCookies.
+// The object is then validated if ValidateTags is enabled. In CollectErrors mode, the binding and validation errors
+// of all sources are returned together as BindingErrors.
 func (b *DefaultRequestBinder) Bind(req *Request, v any) error {
+	if err := b.BindDefaults(req, v); err != nil {
+		return err
+	}
+	var errs BindingErrors
+	if err := b.collectError(&errs, b.BindPathParams(req, v)); err != nil {
+		return err
+	}
 	method := req.Method
 	if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
-		if err := b.BindBody(req, v); err != nil {
+		if err := b.collectError(&errs, b.BindBody(req, v)); err != nil {
 			return err
 		}
 	}
-	return b.BindQueryParams(req, v)
+	if err := b.collectError(&errs, b.BindQueryParams(req, v)); err != nil {
+		return err
+	}
+	if err := b.collectError(&errs, b.BindHeaders(req, v)); err != nil {
+		return err
+	}
+	if err := b.collectError(&errs, b.BindCookies(req, v)); err != nil {
+		return err
+	}
+	if err := b.validateBound(v, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// collectError adds err to errs if it is a binding error in CollectErrors mode, and returns any other error.
+func (b *DefaultRequestBinder) collectError(errs *BindingErrors, err error) error {
+	if !b.CollectErrors {
+		return err
+	}
+	switch e := err.(type) {
+	case BindingErrors:
+		*errs = append(*errs, e...)
+	case *BindingError:
+		*errs = append(*errs, e)
+	default:
+		return err
+	}
+	return nil
+}
+
+// validateBound validates v if ValidateTags is enabled. In CollectErrors mode, the validation errors are added to
+// errs, except those of fields which already failed to bind.
+func (b *DefaultRequestBinder) validateBound(v any, errs *BindingErrors) error {
+	if !b.ValidateTags {
+		return nil
+	}
+	err := ValidateStruct(v)
+	bes, ok := err.(BindingErrors)
+	if !ok || len(*errs) == 0 {
+		return b.collectError(errs, err)
+	}
+	failed := make(map[string]bool, len(*errs))
+	for _, be := range *errs {
+		failed[be.Field] = true
+	}
+	for _, be := range bes {
+		if !failed[be.Field] {
+			*errs = append(*errs, be)
+		}
+	}
+	return nil
 }
 
 // bindData will bind data ONLY fields in destination struct that have EXPLICIT tag.
+//
+// Nested structs, pointers to structs, maps and slices can be bound with keys in bracket or dot notation,
+// e.g. "filter[status]", "items[0].qty", "ids[]" and "user.address.city".
 func (b *DefaultRequestBinder) bindData(destination any, data map[string][]string, tag string) error {
 	if destination == nil || len(data) == 0 {
 		return nil
@@ -106,92 +453,609 @@ func (b *DefaultRequestBinder) bindData(destination any, data map[string][]strin
 
 	// !struct
 	if typ.Kind() != reflect.Struct {
-		if tag == "query" {
+		if tag == "query" || tag == "header" || tag == "cookie" {
 			// incompatible type, data is probably to be found in the body
 			return nil
 		}
 		return errors.New("binding element must be a struct")
 	}
 
-	for i := 0; i < typ.NumField(); i++ {
-		typeField := typ.Field(i)
-		structField := val.Field(i)
-		if typeField.Anonymous {
-			if structField.Kind() == reflect.Ptr {
-				structField = structField.Elem()
+	c := &bindCollector{collect: b.CollectErrors, elements: b.maxParamElements()}
+	if _, err := b.bindStruct(val, &bindParams{values: data}, tag, 1, c); err != nil {
+		return err
+	}
+	if len(c.errs) > 0 {
+		return c.errs
+	}
+	return nil
+}
+
+func (b *DefaultRequestBinder) maxParamElements() int {
+	if b.MaxParamElements <= 0 {
+		return defaultMaxParamElements
+	}
+	return b.MaxParamElements
+}
+
+// bindCollector holds the state of a bindData call. It collects the errors of converting values of fields
+// in CollectErrors mode, tracks the path of the field being bound, e.g. "items[0].qty",
+// and counts down the slice elements which may still be allocated.
+type bindCollector struct {
+	collect  bool
+	errs     BindingErrors
+	segments []string
+	elements int
+}
+
+func (c *bindCollector) enter(segment string) {
+	c.segments = append(c.segments, segment)
+}
+
+func (c *bindCollector) leave() {
+	c.segments = c.segments[:len(c.segments)-1]
+}
+
+// add collects the error of converting values of the field at segment, or returns err if not in CollectErrors mode.
+func (c *bindCollector) add(segment string, field reflect.Value, values []string, err error) error {
+	if !c.collect {
+		return err
+	}
+	path := strings.TrimPrefix(strings.Join(c.segments, "")+segment, ".")
+	message := fmt.Sprintf("failed to bind field value to %v", field.Type())
+	c.errs = append(c.errs, NewBindingError(path, values, message, err).(*BindingError))
+	return nil
+}
+
+// bindDataError converts an error of bindData into an HTTPError, unless it is BindingErrors.
+func bindDataError(err error) error {
+	if bes, ok := err.(BindingErrors); ok {
+		return bes
+	}
+	return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+}
+
+// bindStruct binds data to the fields of struct val and reports whether any field was bound.
+// The fields are iterated according to the cached bindPlan of the struct type.
+func (b *DefaultRequestBinder) bindStruct(val reflect.Value, data *bindParams, tag string, depth int, c *bindCollector) (bool, error) {
+	bound := false
+	for _, f := range cachedBindPlan(val.Type(), tag).fields {
+		structField := val.Field(f.index)
+		inputFieldName := f.name
+		if f.anonymous && f.kind == reflect.Ptr {
+			if structField.IsNil() {
+				if !f.elemStruct || inputFieldName != "" {
+					continue
+				}
+				// allocate the embedded struct only if any of its fields is bound
+				ok, err := b.bindNested(structField, data.values, tag, depth, c)
+				if err != nil {
+					return bound, err
+				}
+				bound = bound || ok
+				continue
 			}
+			structField = structField.Elem()
 		}
-		if !structField.CanSet() {
-			continue
-		}
-		structFieldKind := structField.Kind()
-		inputFieldName := typeField.Tag.Get(tag)
-		if typeField.Anonymous && structField.Kind() == reflect.Struct && inputFieldName != "" {
+		if f.anonymous && f.elemStruct && inputFieldName != "" {
 			// if anonymous struct with query/form tags, report an error
-			return errors.New("query/form tags are not allowed with anonymous struct field")
+			return bound, errors.New("query/form tags are not allowed with anonymous struct field")
 		}
 
 		if inputFieldName == "" {
 			// if tag is nil, we inspect if the field is a not BindUnmarshaler struct and try to bind data into it (might contains fields with tags),
 			// structs that implement BindUnmarshaler are bound only when they have explicit tag
-			if _, ok := structField.Addr().Interface().(BindUnmarshaler); !ok && structFieldKind == reflect.Struct {
-				if err := b.bindData(structField.Addr().Interface(), data, tag); err != nil {
-					return err
+			if !f.unmarshaler && f.elemStruct {
+				ok, err := b.bindStruct(structField, data, tag, depth, c)
+				if err != nil {
+					return bound, err
 				}
+				bound = bound || ok
 			}
-			// does not have explicit tag and is not an ordinary struct - so move to next field
 			continue
 		}
 
-		inputValue, exists := data[inputFieldName]
+		inputValue, exists := data.get(inputFieldName)
 		if !exists {
-			// json.Unmarshal supports case insensitive binding.
-			// However the url params are bound case sensitive which is inconsistent.
-			// To fix this we must check all of the map values in a case-insensitive search.
-			for k, v := range data {
-				if strings.EqualFold(k, inputFieldName) {
-					inputValue = v
-					exists = true
-					break
+			// the field may be bound with nested keys, e.g. "name[key]" or "name.key"
+			if !data.hasNested(inputFieldName) {
+				continue
+			}
+			if nested := subData(data.values, inputFieldName); len(nested) > 0 {
+				c.enter("." + inputFieldName)
+				ok, err := b.bindNested(structField, nested, tag, depth+1, c)
+				c.leave()
+				if err != nil {
+					return bound, fmt.Errorf("%s: %w", inputFieldName, err)
 				}
+				bound = bound || ok
 			}
+			continue
 		}
 
-		if !exists {
+		var err error
+		if f.layout != "" || f.unit != "" {
+			err = setTimeField(structField, inputValue, f.layout, f.unit)
+		} else {
+			err = setField(structField, inputValue)
+		}
+		if err != nil {
+			if err = c.add("."+inputFieldName, structField, inputValue, err); err != nil {
+				return bound, err
+			}
 			continue
 		}
+		bound = true
+	}
+	return bound, nil
+}
 
-		// call this first in case we're dealing with an alias to an array type
-		if ok, err := unmarshalField(typeField.Type.Kind(), inputValue[0], structField); ok {
-			if err != nil {
+// bindNested binds data with nested keys to a struct, map or slice field, or to a pointer to any of them.
+// Nil pointers are allocated only if anything is bound. It reports whether anything was bound.
+func (b *DefaultRequestBinder) bindNested(field reflect.Value, data map[string][]string, tag string, depth int, c *bindCollector) (bool, error) {
+	maxDepth := b.MaxParamDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxParamDepth
+	}
+	if depth > maxDepth {
+		return false, fmt.Errorf("exceeds maximum nesting depth of %d", maxDepth)
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		if !field.IsNil() {
+			return b.bindNested(field.Elem(), data, tag, depth, c)
+		}
+		elem := reflect.New(field.Type().Elem())
+		ok, err := b.bindNested(elem.Elem(), data, tag, depth, c)
+		if ok && err == nil {
+			field.Set(elem)
+		}
+		return ok, err
+	case reflect.Struct:
+		if _, ok := field.Addr().Interface().(BindUnmarshaler); ok {
+			return false, nil
+		}
+		return b.bindStruct(field, &bindParams{values: data}, tag, depth, c)
+	case reflect.Map:
+		return b.bindMap(field, data, tag, depth, c)
+	case reflect.Slice:
+		return b.bindSlice(field, data, tag, depth, c)
+	}
+	return false, nil
+}
+
+// bindNestedAt binds data with nested keys to the element of a map or slice field at segment, e.g. "[0]".
+func (b *DefaultRequestBinder) bindNestedAt(segment string, elem reflect.Value, data map[string][]string, tag string, depth int, c *bindCollector) error {
+	c.enter(segment)
+	defer c.leave()
+	_, err := b.bindNested(elem, data, tag, depth, c)
+	return err
+}
+
+// bindMap binds data to map field by the first segment of the keys, e.g. "status" of "status" or "address.city".
+func (b *DefaultRequestBinder) bindMap(field reflect.Value, data map[string][]string, tag string, depth int, c *bindCollector) (bool, error) {
+	typ := field.Type()
+	if typ.Key().Kind() != reflect.String {
+		return false, nil
+	}
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(typ))
+	}
+	for _, key := range firstSegments(data) {
+		elem := reflect.New(typ.Elem()).Elem()
+		if values, ok := data[key]; ok {
+			if err := setField(elem, values); err != nil {
+				if err = c.add("["+key+"]", elem, values, err); err != nil {
+					return true, fmt.Errorf("%s: %w", key, err)
+				}
+				continue
+			}
+		} else if err := b.bindNestedAt("["+key+"]", elem, subData(data, key), tag, depth+1, c); err != nil {
+			return true, fmt.Errorf("%s: %w", key, err)
+		}
+		field.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), elem)
+	}
+	return true, nil
+}
+
+// bindSlice binds data to slice field by index, e.g. "0" or "0.qty". Values of keys with an empty index,
+// e.g. "ids[]", are appended after the indexed elements.
+func (b *DefaultRequestBinder) bindSlice(field reflect.Value, data map[string][]string, tag string, depth int, c *bindCollector) (bool, error) {
+	maxIndex := b.MaxParamIndex
+	if maxIndex <= 0 {
+		maxIndex = defaultMaxParamIndex
+	}
+	length := 0
+	indices := make(map[string]int)
+	for _, key := range firstSegments(data) {
+		if key == "" {
+			continue
+		}
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return false, fmt.Errorf("invalid index %q", key)
+		}
+		if index > maxIndex {
+			return false, fmt.Errorf("index %d exceeds maximum index of %d", index, maxIndex)
+		}
+		indices[key] = index
+		if index >= length {
+			length = index + 1
+		}
+	}
+	appended := data[""]
+	if length+len(appended) > c.elements {
+		return false, fmt.Errorf("exceeds maximum number of %d slice elements", b.maxParamElements())
+	}
+	c.elements -= length + len(appended)
+
+	typ := field.Type()
+	slice := reflect.MakeSlice(typ, length+len(appended), length+len(appended))
+	for key, index := range indices {
+		elem := slice.Index(index)
+		if values, ok := data[key]; ok {
+			if err := setField(elem, values); err != nil {
+				if err = c.add("["+key+"]", elem, values, err); err != nil {
+					return true, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+		} else if err := b.bindNestedAt("["+key+"]", elem, subData(data, key), tag, depth+1, c); err != nil {
+			return true, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	for i, value := range appended {
+		elem := slice.Index(length + i)
+		if err := setField(elem, []string{value}); err != nil {
+			if err = c.add("["+strconv.Itoa(length+i)+"]", elem, []string{value}, err); err != nil {
+				return true, err
+			}
+		}
+	}
+	field.Set(slice)
+	return true, nil
+}
+
+// setField sets field to values converted to the type of field.
+func setField(field reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	// call this first in case we're dealing with an alias to an array type
+	if ok, err := unmarshalField(field.Kind(), values[0], field); ok {
+		return err
+	}
+
+	if field.Kind() == reflect.Slice {
+		sliceOf := field.Type().Elem().Kind()
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for j := 0; j < len(values); j++ {
+			if err := setWithProperType(sliceOf, values[j], slice.Index(j)); err != nil {
 				return err
 			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setWithProperType(field.Kind(), values[0], field)
+}
+
+// setDefaults sets the fields of struct val that have a "default" tag and a zero value.
+func setDefaults(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		field := val.Field(i)
+		if !field.CanSet() {
 			continue
 		}
+		value, ok := typeField.Tag.Lookup("default")
+		if !ok {
+			// fields of nested structs may have defaults
+			if field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				if err := setDefaults(field); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsZero() {
+			continue
+		}
+		if layout := typeField.Tag.Get("layout"); layout != "" {
+			if err := setTimeField(field, strings.Split(value, ","), layout, ""); err != nil {
+				return fmt.Errorf("invalid default of field %s: %w", typeField.Name, err)
+			}
+			continue
+		}
+		if err := setDefault(field, value); err != nil {
+			return fmt.Errorf("invalid default of field %s: %w", typeField.Name, err)
+		}
+	}
+	return nil
+}
+
+func setDefault(field reflect.Value, value string) error {
+	if ok, err := unmarshalField(field.Kind(), value, field); ok {
+		return err
+	}
+	if field.Kind() == reflect.Slice {
+		return setField(field, strings.Split(value, ","))
+	}
+	return setWithProperType(field.Kind(), value, field)
+}
+
+// subData returns the data with keys nested under name, with name stripped from the keys,
+// e.g. "status" for "filter[status]" and "0.qty" for "items[0].qty" under "filter" and "items".
+// Names are matched case-insensitively.
+func subData(data map[string][]string, name string) map[string][]string {
+	var sub map[string][]string
+	for k, v := range data {
+		if len(k) <= len(name) || !strings.EqualFold(k[:len(name)], name) {
+			continue
+		}
+		var key string
+		switch rest := k[len(name):]; rest[0] {
+		case '.':
+			key = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				continue
+			}
+			key = rest[1:end] + rest[end+1:]
+		default:
+			continue
+		}
+		if sub == nil {
+			sub = make(map[string][]string)
+		}
+		sub[key] = append(sub[key], v...)
+	}
+	return sub
+}
+
+// firstSegments returns the distinct first segments of the keys of data, e.g. "address" of "address.city".
+func firstSegments(data map[string][]string) []string {
+	seen := make(map[string]bool)
+	var segments []string
+	for k := range data {
+		if i := strings.IndexAny(k, ".["); i >= 0 {
+			k = k[:i]
+		}
+		if !seen[k] {
+			seen[k] = true
+			segments = append(segments, k)
+		}
+	}
+	sort.Strings(segments)
+	return segments
+}
+
+// tagNames returns the names in tag of the fields of struct typ, including the fields of nested structs
+// which bindData would bind data into.
+func tagNames(typ reflect.Type, tag string) []string {
+	var names []string
+	for _, field := range taggedFields(typ, tag) {
+		name, _ := parseTag(field.Tag.Get(tag))
+		names = append(names, name)
+	}
+	return names
+}
+
+// taggedFields returns the fields of struct type typ, including those of embedded and nested structs, that have tag.
+// The result is cached per type and tag.
+func taggedFields(typ reflect.Type, tag string) []reflect.StructField {
+	key := bindPlanKey{typ, tag}
+	if fields, ok := taggedFieldCache.Load(key); ok {
+		return fields.([]reflect.StructField)
+	}
+	var fields []reflect.StructField
+	visited := make(map[reflect.Type]bool)
+	var walk func(typ reflect.Type)
+	walk = func(typ reflect.Type) {
+		for typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ == nil || typ.Kind() != reflect.Struct || visited[typ] {
+			return
+		}
+		visited[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if name, _ := parseTag(field.Tag.Get(tag)); name != "" {
+				fields = append(fields, field)
+			} else if field.Anonymous || field.Type.Kind() == reflect.Struct {
+				walk(field.Type)
+			}
+		}
+	}
+	walk(typ)
+	taggedFieldCache.Store(key, fields)
+	return fields
+}
+
+// bindFiles binds files of a multipart form to the fields of bindable object with a "form" tag, which are of type
+// *multipart.FileHeader, UploadedFile or *UploadedFile, or a slice of them. The tag options "maxsize" and "accept",
+// e.g. `form:"avatar,maxsize=5MB,accept=image/png image/jpeg"`, limit the size and the sniffed media type of files.
+func bindFiles(i any, files map[string][]*multipart.FileHeader) error {
+	val := reflect.ValueOf(i)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return bindFileFields(val.Elem(), files)
+}
 
-		numElems := len(inputValue)
-		if structFieldKind == reflect.Slice && numElems > 0 {
-			sliceOf := structField.Type().Elem().Kind()
-			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-			for j := 0; j < numElems; j++ {
-				if err := setWithProperType(sliceOf, inputValue[j], slice.Index(j)); err != nil {
+func bindFileFields(val reflect.Value, files map[string][]*multipart.FileHeader) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		field := val.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		name, opts := parseTag(typeField.Tag.Get("form"))
+		if name == "" {
+			// fields of nested structs may be files
+			if field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct && field.Type() != uploadedFileType {
+				if err := bindFileFields(field, files); err != nil {
 					return err
 				}
 			}
-			val.Field(i).Set(slice)
-		} else if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-			return err
+			continue
+		}
 
+		elemType := field.Type()
+		isSlice := elemType.Kind() == reflect.Slice
+		if isSlice {
+			elemType = elemType.Elem()
+		}
+		if elemType != fileHeaderType && elemType != uploadedFileType && elemType != reflect.PtrTo(uploadedFileType) {
+			continue
+		}
+		fhs, ok := files[name]
+		if !ok {
+			for k, v := range files {
+				if strings.EqualFold(k, name) {
+					fhs = v
+					break
+				}
+			}
+		}
+		if len(fhs) == 0 {
+			continue
+		}
+		if !isSlice {
+			fhs = fhs[:1]
+		}
+
+		values := reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(fhs))
+		for _, fh := range fhs {
+			file, err := checkFile(name, fh, opts, elemType != fileHeaderType)
+			if err != nil {
+				return err
+			}
+			switch elemType {
+			case fileHeaderType:
+				values = reflect.Append(values, reflect.ValueOf(fh))
+			case uploadedFileType:
+				values = reflect.Append(values, reflect.ValueOf(*file))
+			default:
+				values = reflect.Append(values, reflect.ValueOf(file))
+			}
+		}
+		if isSlice {
+			field.Set(values)
+		} else {
+			field.Set(values.Index(0))
 		}
 	}
 	return nil
 }
 
+// checkFile checks the file of field name against the "maxsize" and "accept" tag options.
+// The file is sniffed only if sniff is true or its media type has to be checked.
+func checkFile(name string, fh *multipart.FileHeader, opts tagOptions, sniff bool) (*UploadedFile, error) {
+	if value, ok := opts.Get("maxsize"); ok {
+		maxSize, err := parseByteSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxsize of field %s: %w", name, err)
+		}
+		if fh.Size > maxSize {
+			return nil, &BindingError{
+				Field:     name,
+				Values:    []string{fh.Filename},
+				HTTPError: NewHTTPError(http.StatusRequestEntityTooLarge, "file exceeds maximum size of "+value),
+			}
+		}
+	}
+	accept, ok := opts.Get("accept")
+	if !ok && !sniff {
+		return nil, nil
+	}
+	file, err := newUploadedFile(fh)
+	if err != nil {
+		return nil, NewBindingError(name, []string{fh.Filename}, "failed to read file", err)
+	}
+	if ok && !matchMediaTypes(strings.Fields(accept), file.ContentType) {
+		return nil, &BindingError{
+			Field:     name,
+			Values:    []string{fh.Filename},
+			HTTPError: NewHTTPError(http.StatusUnsupportedMediaType, "file type "+file.ContentType+" is not accepted"),
+		}
+	}
+	return file, nil
+}
+
+// parseByteSize parses a number of bytes with an optional unit of "B", "KB", "MB" or "GB" in powers of 1024, e.g. "5MB".
+func parseByteSize(s string) (int64, error) {
+	number := strings.TrimSpace(s)
+	shift := 0
+	upper := strings.ToUpper(number)
+	for i, unit := range []string{"KB", "MB", "GB"} {
+		if strings.HasSuffix(upper, unit) {
+			number, shift = number[:len(number)-2], 10*(i+1)
+			break
+		}
+	}
+	if shift == 0 && strings.HasSuffix(upper, "B") {
+		number = number[:len(number)-1]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	return n << shift, nil
+}
+
+// tagOptions is the comma-separated options following the name in a struct tag, e.g. "signed" of `cookie:"session,signed"`.
+type tagOptions string
+
+// parseTag splits a struct tag into its name and options.
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opts)
+}
+
+// Get returns the value of the option name, e.g. "5MB" of "maxsize=5MB", and reports whether the option is present.
+func (o tagOptions) Get(name string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var next string
+		next, s, _ = strings.Cut(s, ",")
+		if key, value, ok := strings.Cut(next, "="); ok && key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Contains reports whether the options contain opt.
+func (o tagOptions) Contains(opt string) bool {
+	s := string(o)
+	for s != "" {
+		var next string
+		next, s, _ = strings.Cut(s, ",")
+		if next == opt {
+			return true
+		}
+	}
+	return false
+}
+
 func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	// but also call it here, in case we're dealing with an array of BindUnmarshalers
 	if ok, err := unmarshalField(valueKind, val, structField); ok {
 		return err
 	}
+	if valueKind == reflect.Int64 && structField.Type() == durationType {
+		return setDurationField(val, structField)
+	}
 
 	switch valueKind {
 	case reflect.Ptr:
@@ -259,6 +1123,88 @@ func unmarshalFieldPtr(value string, field reflect.Value) (bool, error) {
 	return unmarshalFieldNonPtr(value, field.Elem())
 }
 
+// setDurationField sets a time.Duration field to value parsed with time.ParseDuration, e.g. "1m30s",
+// or to value as a number of nanoseconds if it is an integer, e.g. "1000000000".
+func setDurationField(value string, field reflect.Value) error {
+	if value == "" {
+		value = "0"
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		n, nerr := strconv.ParseInt(value, 10, 64)
+		if nerr != nil {
+			return err
+		}
+		d = time.Duration(n)
+	}
+	field.SetInt(int64(d))
+	return nil
+}
+
+// setTimeField sets a time.Time field, or a pointer or slice of them, to values parsed with layout,
+// or as Unix times in unit, which is one of "unix", "unixmilli" and "unixnano". Fields of other types are set
+// with setField. Empty values are set as the zero time.
+func setTimeField(field reflect.Value, values []string, layout, unit string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	typ := field.Type()
+	elemType := typ
+	if elemType.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType != timeType {
+		return setField(field, values)
+	}
+
+	set := func(dst reflect.Value, value string) error {
+		var t time.Time
+		if value != "" {
+			var err error
+			if t, err = parseTime(value, layout, unit); err != nil {
+				return err
+			}
+		}
+		if dst.Kind() == reflect.Ptr {
+			dst.Set(reflect.New(timeType))
+			dst = dst.Elem()
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if typ.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(typ, len(values), len(values))
+		for i, value := range values {
+			if err := set(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return set(field, values[0])
+}
+
+func parseTime(value, layout, unit string) (time.Time, error) {
+	if unit == "" {
+		return time.Parse(layout, value)
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch unit {
+	case "unixmilli":
+		return time.UnixMilli(n), nil
+	case "unixnano":
+		return time.Unix(0, n), nil
+	}
+	return time.Unix(n, 0), nil
+}
+
 func setIntField(value string, bitSize int, field reflect.Value) error {
 	if value == "" {
 		value = "0"
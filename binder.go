@@ -16,6 +16,8 @@ type BindingError struct {
 	Field string `json:"field"`
 	// Values of parameter that failed to bind.
 	Values []string `json:"-"`
+	// Offset is the byte offset in the request body where decoding failed, if any.
+	Offset int64 `json:"offset,omitempty"`
 }
 
 // NewBindingError creates new instance of binding error.
@@ -36,6 +38,63 @@ func (be *BindingError) Error() string {
 	return fmt.Sprintf("%s, field=%s", be.HTTPError.Error(), be.Field)
 }
 
+// BindingErrors represents multiple errors that occurred while binding or validating request data.
+type BindingErrors []*BindingError
+
+// Error returns the error messages joined by "; ".
+func (e BindingErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, be := range e {
+		messages[i] = be.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors.
+func (e BindingErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, be := range e {
+		errs[i] = be
+	}
+	return errs
+}
+
+// bodyErrorField is the key of the messages of errors not caused by a particular field,
+// e.g. syntax errors of the request body.
+const bodyErrorField = "_body"
+
+// messages returns the messages of the errors by field. Messages of the same field are joined by "; ".
+func (e BindingErrors) messages() map[string]string {
+	messages := make(map[string]string, len(e))
+	for _, be := range e {
+		field := be.Field
+		if field == "" {
+			field = bodyErrorField
+		}
+		if message, ok := messages[field]; ok {
+			messages[field] = message + "; " + be.Message
+		} else {
+			messages[field] = be.Message
+		}
+	}
+	return messages
+}
+
+// httpError returns an HTTPError with the status code shared by all errors, or 400 if they differ.
+func (e BindingErrors) httpError() *HTTPError {
+	code := http.StatusBadRequest
+	messages := make([]string, len(e))
+	for i, be := range e {
+		if i == 0 {
+			code = be.Code
+		} else if be.Code != code {
+			code = http.StatusBadRequest
+		}
+		messages[i] = be.Field + ": " + be.Message
+	}
+	return WrapHTTPError(e, code, strings.Join(messages, "; "))
+}
+
 // ValueBinder provides utility methods for binding parameters to various Go built-in types.
 type ValueBinder struct {
 	// failFast is flag for binding methods to return without attempting to bind when previous binding already failed
@@ -66,6 +125,66 @@ func QueryParamsBinder(req *Request) *ValueBinder {
 	}
 }
 
+// PathParamsBinder creates path parameter value binder.
+// Path parameters are extracted with PathParamExtractor.
+func PathParamsBinder(req *Request) *ValueBinder {
+	return &ValueBinder{
+		failFast:  true,
+		ValueFunc: req.Param,
+		ValuesFunc: func(sourceParam string) []string {
+			value := req.Param(sourceParam)
+			if value == "" {
+				return nil
+			}
+			return []string{value}
+		},
+		ErrorFunc: NewBindingError,
+	}
+}
+
+// HeadersBinder creates header value binder.
+// Headers are matched case-insensitively, and ValuesFunc returns all values of a header repeated in multiple lines.
+func HeadersBinder(req *Request) *ValueBinder {
+	return &ValueBinder{
+		failFast:  true,
+		ValueFunc: req.Header.Get,
+		ValuesFunc: func(sourceParam string) []string {
+			values := req.Header.Values(sourceParam)
+			if len(values) == 0 {
+				return nil
+			}
+			return values
+		},
+		ErrorFunc: NewBindingError,
+	}
+}
+
+// CookiesBinder creates cookie value binder.
+// ValuesFunc returns the values of all cookies with the name, which may be sent for different paths.
+func CookiesBinder(req *Request) *ValueBinder {
+	vb := &ValueBinder{
+		failFast:  true,
+		ErrorFunc: NewBindingError,
+	}
+	vb.ValuesFunc = func(sourceParam string) []string {
+		var values []string
+		for _, cookie := range req.Cookies() {
+			if cookie.Name == sourceParam {
+				values = append(values, cookie.Value)
+			}
+		}
+		return values
+	}
+	vb.ValueFunc = func(sourceParam string) string {
+		values := vb.ValuesFunc(sourceParam)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	return vb
+}
+
 // FormParamsBinder creates form param binder.
 // For all requests, FormParamsBinder parses the raw query from the URL and uses query params as form params.
 //
@@ -79,16 +198,13 @@ func QueryParamsBinder(req *Request) *ValueBinder {
 // See: https://golang.org/pkg/net/http/#Request.ParseForm
 func FormParamsBinder(req *Request) *ValueBinder {
 	vb := &ValueBinder{
-		failFast: true,
-		ValueFunc: func(sourceParam string) string {
-			return req.FormValue(sourceParam)
-		},
+		failFast:  true,
 		ErrorFunc: NewBindingError,
 	}
 	vb.ValuesFunc = func(sourceParam string) []string {
 		if req.Form == nil {
-			// this is same as Request.FormValue() does internally
-			_ = req.ParseMultipartForm(32 << 20)
+			// this is same as Request.FormValue() does internally, but within the body limits of the request
+			_, _ = req.FormParams()
 		}
 		values, ok := req.Form[sourceParam]
 		if !ok {
@@ -96,6 +212,13 @@ func FormParamsBinder(req *Request) *ValueBinder {
 		}
 		return values
 	}
+	vb.ValueFunc = func(sourceParam string) string {
+		values := vb.ValuesFunc(sourceParam)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
 
 	return vb
 }
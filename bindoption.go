@@ -0,0 +1,398 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BindOption configures the sources and behaviour of binding for Request.Bind.
+type BindOption int
+
+const (
+	// FromPath binds path params.
+	FromPath BindOption = iota + 1
+	// FromBody binds the request body of POST, PUT and PATCH requests.
+	FromBody
+	// FromQuery binds query params.
+	FromQuery
+	// FromHeader binds request headers.
+	FromHeader
+	// FromCookie binds request cookies.
+	FromCookie
+	// RejectConflicts rejects different values provided by multiple sources for the same field.
+	RejectConflicts
+	// AllowDeleteBody binds the request body of DELETE requests as well.
+	AllowDeleteBody
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+var errPatchBindWith = errors.New("patch documents cannot be bound with bind options, use Request.BindPatch instead")
+
+// defaultBindSources are the sources bound by BindWith if no source is given, in order of precedence
+// equivalent to the order of DefaultRequestBinder.Bind, where a later step overrides an earlier one.
+var defaultBindSources = []BindOption{FromCookie, FromHeader, FromQuery, FromBody, FromPath}
+
+// String returns the name of the option.
+func (o BindOption) String() string {
+	switch o {
+	case FromPath:
+		return "path"
+	case FromBody:
+		return "body"
+	case FromQuery:
+		return "query"
+	case FromHeader:
+		return "header"
+	case FromCookie:
+		return "cookie"
+	case RejectConflicts:
+		return "reject conflicts"
+	case AllowDeleteBody:
+		return "allow delete body"
+	}
+	return fmt.Sprintf("BindOption(%d)", int(o))
+}
+
+// BindWith binds data from the sources given in opts to bindable object, in order of precedence: a field provided
+// by a source, i.e. with a key in the data of the source, is not overridden by any later source, even if its value
+// is a zero value. All sources of DefaultRequestBinder.Bind are bound if no source is given.
+//
+// Every source is bound into a separate instance of the object, and the provided fields are then merged into it.
+// The keys of JSON and form bodies are inspected, whereas a field is provided by a body of any other media type
+// if it is bound to a value other than its default or zero value. Patch documents, e.g. "application/merge-patch+json",
+// are rejected with status code 415, since they apply to the existing value of the object, see Request.BindPatch.
+// A BindingError is returned for a field provided with different values by multiple sources if RejectConflicts
+// is given. Defaults and validation are applied as DefaultRequestBinder.Bind does.
+func (b *DefaultRequestBinder) BindWith(req *Request, v any, opts ...BindOption) error {
+	var sources []BindOption
+	var rejectConflicts, allowDeleteBody bool
+	seen := make(map[BindOption]bool)
+	for _, opt := range opts {
+		switch opt {
+		case RejectConflicts:
+			rejectConflicts = true
+		case AllowDeleteBody:
+			allowDeleteBody = true
+		case FromPath, FromBody, FromQuery, FromHeader, FromCookie:
+			if !seen[opt] {
+				seen[opt] = true
+				sources = append(sources, opt)
+			}
+		}
+	}
+	if len(sources) == 0 {
+		sources = defaultBindSources
+	}
+
+	if err := b.BindDefaults(req, v); err != nil {
+		return err
+	}
+	var errs BindingErrors
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		// only structs can be merged, so the sources are bound in reverse order of precedence
+		for i := len(sources) - 1; i >= 0; i-- {
+			_, err := b.bindSource(req, v, sources[i], allowDeleteBody)
+			if err = b.collectError(&errs, err); err != nil {
+				return err
+			}
+		}
+	} else {
+		typ := val.Elem().Type()
+		// fields of the base instance have their default or zero values, which are not provided by any source
+		base := reflect.New(typ)
+		if err := b.BindDefaults(req, base.Interface()); err != nil {
+			return err
+		}
+		m := &bindMerger{rejectConflicts: rejectConflicts, provided: make(map[string]BindOption)}
+		for _, source := range sources {
+			bound := reflect.New(typ)
+			if err := b.BindDefaults(req, bound.Interface()); err != nil {
+				return err
+			}
+			fields, err := b.bindSource(req, bound.Interface(), source, allowDeleteBody)
+			if err = b.collectError(&errs, err); err != nil {
+				return err
+			}
+			m.merge(val.Elem(), bound.Elem(), base.Elem(), "", "", source, fields)
+			if len(m.conflicts) > 0 {
+				if err := b.collectError(&errs, m.conflicts); err != nil {
+					return err
+				}
+				m.conflicts = nil
+			}
+		}
+	}
+
+	if err := b.validateBound(v, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// bindSource binds data from source to bindable object, and returns the fields provided by the source,
+// or nil if they are determined by comparing the bound values with the default values instead.
+func (b *DefaultRequestBinder) bindSource(req *Request, v any, source BindOption, allowDeleteBody bool) (providedFields, error) {
+	typ := reflect.TypeOf(v)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	fields := make(providedFields)
+	switch source {
+	case FromPath:
+		if err := b.BindPathParams(req, v); err != nil {
+			return nil, err
+		}
+		fields.addParams(typ, &bindParams{values: pathParams(req, typ)}, "param", "")
+	case FromBody:
+		switch req.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		case http.MethodDelete:
+			if !allowDeleteBody {
+				return fields, nil
+			}
+		default:
+			return fields, nil
+		}
+		return b.bindBodySource(req, v, typ)
+	case FromQuery:
+		if err := b.BindQueryParams(req, v); err != nil {
+			return nil, err
+		}
+		fields.addParams(typ, &bindParams{values: req.QueryParams()}, "query", "")
+	case FromHeader:
+		if err := b.BindHeaders(req, v); err != nil {
+			return nil, err
+		}
+		fields.addParams(typ, &bindParams{values: headerParams(req, typ)}, "header", "")
+	case FromCookie:
+		if err := b.BindCookies(req, v); err != nil {
+			return nil, err
+		}
+		cookies, _ := cookieParams(req, typ)
+		fields.addParams(typ, &bindParams{values: cookies}, "cookie", "")
+	}
+	return fields, nil
+}
+
+// bindBodySource binds the request body to bindable object, and returns the fields provided by the keys of
+// a JSON or form body, or nil for a body of any other media type.
+func (b *DefaultRequestBinder) bindBodySource(req *Request, v any, typ reflect.Type) (providedFields, error) {
+	fields := make(providedFields)
+	decoder, _ := lookupDecoder(req.Header.Get(HeaderContentType))
+	if _, ok := decoder.(patchDecoder); ok {
+		// a patch applies to the existing value, rather than to the separate instance of the source
+		return nil, WrapHTTPError(errPatchBindWith, http.StatusUnsupportedMediaType)
+	}
+	switch decoder {
+	case jsonDecoder:
+		// the body is buffered to be read again for its keys
+		if err := req.DecompressBody(); err != nil {
+			return nil, err
+		}
+		if err := req.BufferBody(b.MaxBodyBytes); err != nil {
+			return nil, decodeError(err)
+		}
+		if err := b.BindBody(req, v); err != nil {
+			return nil, err
+		}
+		data, err := req.BodyBytes()
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		var object map[string]json.RawMessage
+		if json.NewDecoder(bytes.NewReader(data)).Decode(&object) == nil && typ.Kind() == reflect.Struct {
+			fields.addJSON(typ, object, "")
+		}
+		return fields, nil
+	case formDecoder:
+		if err := b.BindBody(req, v); err != nil {
+			return nil, err
+		}
+		data, err := req.postFormParams()
+		if err != nil {
+			return fields, nil
+		}
+		if form := req.Request.MultipartForm; form != nil {
+			for key := range form.File {
+				data[key] = nil
+			}
+		}
+		fields.addParams(typ, &bindParams{values: data}, "form", "")
+		return fields, nil
+	}
+	return nil, b.BindBody(req, v)
+}
+
+// providedFields is the set of fields provided by a source, keyed by the indices of the fields
+// from the bound struct, e.g. "2.0" for the first field of the third field.
+type providedFields map[string]bool
+
+// fieldIndex returns the key of the field at index i of the struct at index.
+func fieldIndex(index string, i int) string {
+	if index == "" {
+		return strconv.Itoa(i)
+	}
+	return index + "." + strconv.Itoa(i)
+}
+
+// addParams adds the fields of struct type typ which are bound from data with tag by bindStruct,
+// and reports whether any field was added.
+func (p providedFields) addParams(typ reflect.Type, data *bindParams, tag, index string) bool {
+	if typ.Kind() != reflect.Struct || len(data.values) == 0 {
+		return false
+	}
+	added := false
+	for _, f := range cachedBindPlan(typ, tag).fields {
+		key := fieldIndex(index, f.index)
+		elemType := typ.Field(f.index).Type
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		ok := false
+		if f.name == "" {
+			if f.elemStruct && !f.unmarshaler {
+				ok = p.addParams(elemType, data, tag, key)
+			}
+		} else if _, exists := data.get(f.name); exists {
+			ok = true
+		} else if data.hasNested(f.name) {
+			if nested := subData(data.values, f.name); len(nested) > 0 {
+				ok = true
+				p.addParams(elemType, &bindParams{values: nested}, tag, key)
+			}
+		}
+		if ok {
+			p[key] = true
+			added = true
+		}
+	}
+	return added
+}
+
+// addJSON adds the fields of struct type typ which are decoded from the members of a JSON object,
+// and reports whether any field was added. Members are matched as encoding/json does.
+func (p providedFields) addJSON(typ reflect.Type, object map[string]json.RawMessage, index string) bool {
+	added := false
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _ := parseTag(tag)
+		key := fieldIndex(index, i)
+		elemType := field.Type
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if field.Anonymous && name == "" && elemType.Kind() == reflect.Struct {
+			// fields of embedded structs are promoted
+			if p.addJSON(elemType, object, key) {
+				p[key] = true
+				added = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		raw, ok := jsonMember(object, name)
+		if !ok {
+			continue
+		}
+		p[key] = true
+		added = true
+		if elemType.Kind() == reflect.Struct {
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(raw, &nested) == nil {
+				p.addJSON(elemType, nested, key)
+			}
+		}
+	}
+	return added
+}
+
+// jsonMember returns the member of a JSON object with name, which is matched exactly first and then
+// case-insensitively.
+func jsonMember[T any](object map[string]T, name string) (T, bool) {
+	if member, ok := object[name]; ok {
+		return member, true
+	}
+	for k, member := range object {
+		if strings.EqualFold(k, name) {
+			return member, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// bindMerger merges the fields provided by sources, in order of precedence.
+type bindMerger struct {
+	rejectConflicts bool
+	// provided maps the paths of fields to the sources which provided them.
+	provided  map[string]BindOption
+	conflicts BindingErrors
+}
+
+// merge sets the fields of dst to the fields of src provided by source, unless they are already provided by an
+// earlier source. Nested structs are merged field by field. If fields is nil, the provided fields are those with
+// values other than those in base.
+func (m *bindMerger) merge(dst, src, base reflect.Value, path, index string, source BindOption, fields providedFields) {
+	typ := dst.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := path
+		if !field.Anonymous || field.Type.Kind() != reflect.Struct {
+			fieldPath = joinFieldPath(path, fieldName(field))
+		}
+		key := fieldIndex(index, i)
+		d, s, z := dst.Field(i), src.Field(i), base.Field(i)
+		if isMergedStruct(field.Type) {
+			m.merge(d, s, z, fieldPath, key, source, fields)
+			continue
+		}
+		if fields != nil && !fields[key] || fields == nil && reflect.DeepEqual(s.Interface(), z.Interface()) {
+			// not provided by the source
+			continue
+		}
+		if prev, ok := m.provided[fieldPath]; ok {
+			if m.rejectConflicts && !reflect.DeepEqual(s.Interface(), d.Interface()) {
+				m.conflicts = append(m.conflicts, &BindingError{
+					Field:     fieldPath,
+					HTTPError: NewHTTPError(http.StatusBadRequest, fmt.Sprintf("conflicting values from %s and %s", prev, source)),
+				})
+			}
+			continue
+		}
+		m.provided[fieldPath] = source
+		d.Set(s)
+	}
+}
+
+// isMergedStruct reports whether the fields of a struct type are merged separately, rather than as a whole.
+func isMergedStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	ptr := reflect.PtrTo(typ)
+	return !ptr.Implements(bindUnmarshalerType) && !ptr.Implements(textUnmarshalerType)
+}
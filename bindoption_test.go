@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindOptionTarget struct {
+	Admin bool   `json:"admin" query:"admin" form:"admin"`
+	Role  string `json:"role" query:"role" form:"role"`
+	Limit int    `json:"limit" query:"limit" form:"limit" default:"10"`
+}
+
+func newBindOptionRequest(body string) *Request {
+	r := httptest.NewRequest(http.MethodPost, "/?admin=true&role=root&limit=99", strings.NewReader(body))
+	r.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	return NewRequest(r)
+}
+
+func TestBindWithExplicitZeroValues(t *testing.T) {
+	var v bindOptionTarget
+	err := newBindOptionRequest(`{"admin":false,"role":"","limit":10}`).Bind(&v, FromBody, FromQuery)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != (bindOptionTarget{Admin: false, Role: "", Limit: 10}) {
+		t.Errorf("fields provided by the body were overridden: %+v", v)
+	}
+}
+
+func TestBindWithFormBody(t *testing.T) {
+	var v bindOptionTarget
+	r := httptest.NewRequest(http.MethodPost, "/?admin=true&role=root", strings.NewReader("role=user"))
+	r.Header.Set(HeaderContentType, MIMEApplicationForm)
+	if err := NewRequest(r).Bind(&v, FromBody); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != (bindOptionTarget{Admin: false, Role: "user", Limit: 10}) {
+		t.Errorf("query params were bound from the body: %+v", v)
+	}
+}
+
+func TestBindWithRejectConflicts(t *testing.T) {
+	var v bindOptionTarget
+	err := newBindOptionRequest(`{"admin":false,"role":"","limit":20}`).Bind(&v, FromBody, FromQuery, RejectConflicts)
+	if v != (bindOptionTarget{Admin: false, Role: "", Limit: 20}) {
+		t.Errorf("fields provided by the body were overridden: %+v", v)
+	}
+	var bes BindingErrors
+	if !errors.As(err, &bes) {
+		t.Fatalf("expected BindingErrors, got %v", err)
+	}
+	conflicts := make(map[string]bool)
+	for _, be := range bes {
+		if be.Code != http.StatusBadRequest {
+			t.Errorf("unexpected status code of %s: %d", be.Field, be.Code)
+		}
+		conflicts[be.Field] = true
+	}
+	for _, field := range []string{"admin", "role", "limit"} {
+		if !conflicts[field] {
+			t.Errorf("conflict of %s not reported: %v", field, err)
+		}
+	}
+}
+
+func TestBindWithAbsentFields(t *testing.T) {
+	var v bindOptionTarget
+	err := newBindOptionRequest(`{"role":"user"}`).Bind(&v, FromBody, FromQuery, RejectConflicts)
+	if err == nil || !strings.Contains(err.Error(), "role") {
+		t.Errorf("expected conflict of role, got %v", err)
+	}
+	if v != (bindOptionTarget{Admin: true, Role: "user", Limit: 99}) {
+		t.Errorf("fields absent from the body were not bound from the query: %+v", v)
+	}
+}
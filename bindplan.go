@@ -0,0 +1,146 @@
+package httpx
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var bindUnmarshalerType = reflect.TypeOf((*BindUnmarshaler)(nil)).Elem()
+
+type bindPlanKey struct {
+	typ reflect.Type
+	tag string
+}
+
+var (
+	bindPlanCache    sync.Map // map[bindPlanKey]*bindPlan
+	taggedFieldCache sync.Map // map[bindPlanKey][]reflect.StructField
+)
+
+// bindPlan is the metadata of a struct type needed by bindStruct, computed once per type and tag.
+type bindPlan struct {
+	fields []bindField
+}
+
+// bindField is a field of a struct type which data may be bound into.
+// Fields which can never be bound, e.g. unexported fields, are not included.
+type bindField struct {
+	index int
+	// name is the name in the tag, or empty if the field has no explicit tag.
+	name string
+	kind reflect.Kind
+	// anonymous is true if the field is embedded.
+	anonymous bool
+	// elemStruct is true if the field is a struct, or a pointer to a struct.
+	elemStruct bool
+	// unmarshaler is true if the field, or the struct pointed to by an embedded pointer, implements BindUnmarshaler.
+	unmarshaler bool
+	// layout is the "layout" tag of a time field, e.g. `layout:"2006-01-02"`.
+	layout string
+	// unit is the "unix", "unixmilli" or "unixnano" tag option of a time field, e.g. `query:"since,unix"`.
+	unit string
+}
+
+// cachedBindPlan returns the bindPlan of struct type typ and tag.
+func cachedBindPlan(typ reflect.Type, tag string) *bindPlan {
+	key := bindPlanKey{typ, tag}
+	if plan, ok := bindPlanCache.Load(key); ok {
+		return plan.(*bindPlan)
+	}
+	plan := &bindPlan{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			// unexported fields, including embedded ones, are not settable
+			continue
+		}
+		name, opts := parseTag(field.Tag.Get(tag))
+		elemType := field.Type
+		if field.Anonymous && elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		f := bindField{
+			index:       i,
+			name:        name,
+			kind:        field.Type.Kind(),
+			anonymous:   field.Anonymous,
+			elemStruct:  elemType.Kind() == reflect.Struct,
+			unmarshaler: reflect.PtrTo(elemType).Implements(bindUnmarshalerType),
+			layout:      field.Tag.Get("layout"),
+		}
+		for _, unit := range []string{"unix", "unixmilli", "unixnano"} {
+			if opts.Contains(unit) {
+				f.unit = unit
+			}
+		}
+		if name == "" && !(f.anonymous && f.kind == reflect.Ptr) && (!f.elemStruct || f.unmarshaler) {
+			// does not have explicit tag and is not an ordinary struct
+			continue
+		}
+		plan.fields = append(plan.fields, f)
+	}
+	actual, _ := bindPlanCache.LoadOrStore(key, plan)
+	return actual.(*bindPlan)
+}
+
+// foldThreshold is the number of keys up to which scanning them is cheaper than building the lookup maps.
+const foldThreshold = 8
+
+// bindParams is the data bound by bindStruct, with case-folded lookup maps built lazily on first use.
+type bindParams struct {
+	values map[string][]string
+	// folded maps case-folded keys to keys.
+	folded map[string]string
+	// nested is the set of case-folded first segments of nested keys, e.g. "address" of "address.city".
+	nested map[string]bool
+}
+
+// get returns the values of key name, which is matched exactly first and then case-insensitively.
+func (p *bindParams) get(name string) ([]string, bool) {
+	if values, ok := p.values[name]; ok {
+		return values, true
+	}
+	// json.Unmarshal supports case insensitive binding.
+	// However the url params are bound case sensitive which is inconsistent.
+	// To fix this we must also look up the keys case-insensitively.
+	if len(p.values) <= foldThreshold {
+		for k, v := range p.values {
+			if strings.EqualFold(k, name) {
+				return v, true
+			}
+		}
+		return nil, false
+	}
+	p.fold()
+	if key, ok := p.folded[strings.ToLower(name)]; ok {
+		return p.values[key], true
+	}
+	return nil, false
+}
+
+// hasNested reports whether there may be keys nested under name, e.g. "name[key]" or "name.key".
+func (p *bindParams) hasNested(name string) bool {
+	if len(p.values) <= foldThreshold || strings.ContainsAny(name, ".[") {
+		return true
+	}
+	p.fold()
+	return p.nested[strings.ToLower(name)]
+}
+
+func (p *bindParams) fold() {
+	if p.folded != nil {
+		return
+	}
+	p.folded = make(map[string]string, len(p.values))
+	p.nested = make(map[string]bool)
+	for key := range p.values {
+		lower := strings.ToLower(key)
+		if k, ok := p.folded[lower]; !ok || key < k {
+			p.folded[lower] = key
+		}
+		if i := strings.IndexAny(lower, ".["); i > 0 {
+			p.nested[lower[:i]] = true
+		}
+	}
+}
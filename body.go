@@ -0,0 +1,321 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const defaultMaxMemory = 32 << 20 // 32 MB
+
+// BodyLimits configures how much of a request body is accepted.
+type BodyLimits struct {
+	// MaxBytes is the maximum number of bytes read from the request body. Zero means unlimited.
+	MaxBytes int64
+	// MaxMemory is the maximum number of bytes of a multipart form stored in memory,
+	// the rest of the files are stored on disk in temporary files. Zero means 32 MB.
+	MaxMemory int64
+	// MaxParts is the maximum number of parts of a multipart form. Zero means unlimited.
+	MaxParts int
+	// MaxFiles is the maximum number of files of a multipart form. Zero means unlimited.
+	MaxFiles int
+	// MaxBufferMemory is the maximum number of bytes of a body buffered by Request.BufferBody stored in memory,
+	// the rest of the body is stored on disk in a temporary file. Zero means the whole body is stored in memory.
+	MaxBufferMemory int64
+	// MaxDecompressedBytes is the maximum number of bytes of a body decompressed by Request.DecompressBody,
+	// which protects against decompression bombs. Zero means 32 MB.
+	MaxDecompressedBytes int64
+}
+
+// DefaultBodyLimits are the limits applied to every request body.
+// Set this global variable once before serving any request to change the limits globally,
+// or use BodyLimit and Request.SetBodyLimits to change them per handler.
+var DefaultBodyLimits = BodyLimits{MaxMemory: defaultMaxMemory}
+
+// BodyLimit returns a middleware that applies limits to the request body for the wrapped handler,
+// replacing DefaultBodyLimits.
+func BodyLimit(limits BodyLimits) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HandlerFunc(func(req *Request, res *Responder) error {
+			req.SetBodyLimits(limits)
+			return H(next)(req, res)
+		})
+	}
+}
+
+// SetBodyLimits replaces DefaultBodyLimits with limits for this request.
+// It must be called before the request body is read.
+func (r *Request) SetBodyLimits(limits BodyLimits) {
+	r.limits = &limits
+	r.limitBody(limits.MaxBytes)
+}
+
+func (r *Request) bodyLimits() BodyLimits {
+	limits := DefaultBodyLimits
+	if r.limits != nil {
+		limits = *r.limits
+	}
+	if limits.MaxMemory <= 0 {
+		limits.MaxMemory = defaultMaxMemory
+	}
+	if limits.MaxDecompressedBytes <= 0 {
+		limits.MaxDecompressedBytes = defaultMaxDecompressedBytes
+	}
+	return limits
+}
+
+// limitBody limits the number of bytes read from the request body to n, or removes the limit if n is zero.
+func (r *Request) limitBody(n int64) {
+	if body, ok := r.Request.Body.(*limitedBody); ok {
+		body.limit = n
+		return
+	}
+	if n <= 0 || r.Request.Body == nil || r.Request.Body == http.NoBody {
+		return
+	}
+	r.Request.Body = &limitedBody{ReadCloser: r.Request.Body, limit: n}
+}
+
+// limitedBody is similar to http.MaxBytesReader, except that reading beyond the limit
+// returns ErrStatusRequestEntityTooLarge, and that the limit can be changed before it is reached.
+type limitedBody struct {
+	io.ReadCloser
+
+	limit int64
+	read  int64
+	err   error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.limit <= 0 {
+		n, err := b.ReadCloser.Read(p)
+		b.read += int64(n)
+		return n, err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	remaining := b.limit - b.read
+	if remaining < 0 {
+		remaining = 0
+	}
+	// read one more byte than allowed to tell whether the body exceeds the limit
+	if int64(len(p)) > remaining+1 {
+		p = p[:remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= remaining {
+		b.read += int64(n)
+		return n, err
+	}
+	b.read += remaining
+	b.err = ErrStatusRequestEntityTooLarge
+	return int(remaining), b.err
+}
+
+// bodyBuffer holds a request body read by Request.BufferBody.
+type bodyBuffer struct {
+	data []byte
+	file *os.File
+	size int64
+}
+
+func (b *bodyBuffer) reader() io.ReadCloser {
+	if b.file != nil {
+		return io.NopCloser(io.NewSectionReader(b.file, 0, b.size))
+	}
+	return io.NopCloser(bytes.NewReader(b.data))
+}
+
+// BufferBody reads the request body once so that it can be read again by every later reader.
+// ErrStatusRequestEntityTooLarge is returned if the body exceeds limit bytes, or if limit is zero,
+// BodyLimits.MaxBytes of the request. Calling BufferBody again has no effect.
+//
+// The part of the body exceeding BodyLimits.MaxBufferMemory is stored in a temporary file,
+// which is removed after the request is handled.
+//
+// Request.Body is then served from the buffer again each time it is read by Request.Bind,
+// Request.FormParams and the like, as well as each time it is passed to a handler wrapped by H.
+// Request.GetBody is set to return a new reader of the buffer as well.
+func (r *Request) BufferBody(limit int64) error {
+	if r.body != nil {
+		return nil
+	}
+	buf := new(bodyBuffer)
+	if body := r.Request.Body; body != nil && body != http.NoBody {
+		var src io.Reader = body
+		if limit > 0 {
+			src = &limitedBody{ReadCloser: body, limit: limit}
+		}
+		if err := buf.readFrom(src, r.bodyLimits().MaxBufferMemory); err != nil {
+			return bodyError(err)
+		}
+		_ = body.Close()
+	}
+	r.body = buf
+	r.Request.ContentLength = buf.size
+	r.Request.GetBody = func() (io.ReadCloser, error) {
+		return buf.reader(), nil
+	}
+	r.rewindBody()
+	return nil
+}
+
+func (b *bodyBuffer) readFrom(src io.Reader, maxMemory int64) (err error) {
+	if maxMemory <= 0 {
+		b.data, err = io.ReadAll(src)
+		b.size = int64(len(b.data))
+		return err
+	}
+	if b.data, err = io.ReadAll(io.LimitReader(src, maxMemory+1)); err != nil {
+		return err
+	}
+	b.size = int64(len(b.data))
+	if b.size <= maxMemory {
+		return nil
+	}
+
+	if b.file, err = os.CreateTemp("", "httpx-body-"); err != nil {
+		return err
+	}
+	n, err := io.Copy(b.file, io.MultiReader(bytes.NewReader(b.data), src))
+	b.data, b.size = nil, n
+	if err != nil {
+		b.close()
+	}
+	return err
+}
+
+func (b *bodyBuffer) close() {
+	if b.file != nil {
+		_ = b.file.Close()
+		_ = os.Remove(b.file.Name())
+		b.file = nil
+	}
+}
+
+// BodyBytes returns the request body, buffering it first with BufferBody if it is not yet buffered.
+// The whole body is read into memory even if part of it is stored in a temporary file.
+func (r *Request) BodyBytes() ([]byte, error) {
+	if err := r.BufferBody(0); err != nil {
+		return nil, err
+	}
+	if r.body.file == nil {
+		return r.body.data, nil
+	}
+	return io.ReadAll(r.body.reader())
+}
+
+// rewindBody replaces Request.Body with a new reader of the buffered body, if the body is buffered.
+func (r *Request) rewindBody() {
+	if r.body != nil {
+		r.Request.Body = r.body.reader()
+	}
+}
+
+// release removes the resources held by the request after it is handled.
+func (r *Request) release() {
+	if r.body != nil {
+		r.body.close()
+	}
+}
+
+// bodyError converts an error caused by a request body exceeding its limits into ErrStatusRequestEntityTooLarge.
+func bodyError(err error) error {
+	if errors.Is(err, ErrStatusRequestEntityTooLarge) || errors.Is(err, multipart.ErrMessageTooLarge) {
+		return ErrStatusRequestEntityTooLarge
+	}
+	return err
+}
+
+// parseMultipartForm parses a multipart request body within the limits of the request.
+// It populates Request.Form, Request.PostForm and Request.MultipartForm like http.Request.ParseMultipartForm does.
+func (r *Request) parseMultipartForm() error {
+	if r.Request.MultipartForm != nil {
+		return nil
+	}
+	r.rewindBody()
+	limits := r.bodyLimits()
+	if limits.MaxParts <= 0 && limits.MaxFiles <= 0 {
+		return bodyError(r.Request.ParseMultipartForm(limits.MaxMemory))
+	}
+
+	if r.Request.Form == nil {
+		if err := r.Request.ParseForm(); err != nil {
+			return bodyError(err)
+		}
+	}
+	mr, err := r.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	// multipart.Reader.ReadForm cannot limit the number of parts and files,
+	// so the parts are counted while they are piped into another reader.
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	errc := make(chan error, 1)
+	go func() {
+		err := copyParts(mw, mr, limits)
+		errc <- err
+		pw.CloseWithError(err)
+	}()
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(limits.MaxMemory)
+	pr.Close()
+	if cerr := <-errc; cerr != nil && cerr != io.ErrClosedPipe {
+		err = cerr
+	}
+	if err != nil {
+		if form != nil {
+			_ = form.RemoveAll()
+		}
+		return bodyError(err)
+	}
+
+	if r.Request.PostForm == nil {
+		r.Request.PostForm = make(url.Values)
+	}
+	for k, v := range form.Value {
+		r.Request.Form[k] = append(r.Request.Form[k], v...)
+		r.Request.PostForm[k] = append(r.Request.PostForm[k], v...)
+	}
+	r.Request.MultipartForm = form
+	return nil
+}
+
+func copyParts(dst *multipart.Writer, src *multipart.Reader, limits BodyLimits) error {
+	var parts, files int
+	for {
+		part, err := src.NextRawPart()
+		if err == io.EOF {
+			return dst.Close()
+		}
+		if err != nil {
+			return err
+		}
+		parts++
+		if limits.MaxParts > 0 && parts > limits.MaxParts {
+			return ErrStatusRequestEntityTooLarge
+		}
+		if part.FileName() != "" {
+			files++
+			if limits.MaxFiles > 0 && files > limits.MaxFiles {
+				return ErrStatusRequestEntityTooLarge
+			}
+		}
+		w, err := dst.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(w, part); err != nil {
+			return err
+		}
+	}
+}
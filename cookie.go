@@ -0,0 +1,194 @@
+package httpx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// CookieSigningKeys are the keys used to sign cookie values with HMAC-SHA256.
+	// New cookies are signed with the first key, while every key is accepted for verification.
+	// Rotate keys by prepending a new key, and remove an old key once its cookies have expired.
+	CookieSigningKeys [][]byte
+
+	// CookieEncryptionKeys are the AES keys (16, 24 or 32 bytes long) used to encrypt cookie values with AES-GCM.
+	// New cookies are encrypted with the first key, while every key is accepted for decryption.
+	// Keys are rotated the same way as CookieSigningKeys.
+	CookieEncryptionKeys [][]byte
+)
+
+var (
+	// ErrInvalidCookie is returned when a signed or encrypted cookie cannot be verified.
+	ErrInvalidCookie = errors.New("invalid cookie value")
+
+	errNoCookieKey = errors.New("no cookie key configured")
+)
+
+var cookieEncoding = base64.RawURLEncoding
+
+// SetSignedCookie adds a Set-Cookie header in HTTP response, with the value of cookie
+// signed by the first key of CookieSigningKeys. The value is readable by the client.
+func (r *Responder) SetSignedCookie(cookie *http.Cookie) error {
+	value, err := signCookieValue(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	c := *cookie
+	c.Value = value
+	r.SetCookie(&c)
+	return nil
+}
+
+// SetEncryptedCookie adds a Set-Cookie header in HTTP response, with the value of cookie
+// encrypted and authenticated by the first key of CookieEncryptionKeys.
+func (r *Responder) SetEncryptedCookie(cookie *http.Cookie) error {
+	value, err := encryptCookieValue(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	c := *cookie
+	c.Value = value
+	r.SetCookie(&c)
+	return nil
+}
+
+// DeleteCookie adds a Set-Cookie header in HTTP response that expires the cookie
+// with the same name, path and domain as cookie.
+func (r *Responder) DeleteCookie(cookie *http.Cookie) *Responder {
+	return r.SetCookie(&http.Cookie{
+		Name:     cookie.Name,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: cookie.SameSite,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+	})
+}
+
+// SignedCookie returns the named cookie provided in the request, with its value verified by
+// any key of CookieSigningKeys. If the cookie is not found, http.ErrNoCookie is returned.
+// If the cookie cannot be verified, ErrInvalidCookie is returned.
+func (r *Request) SignedCookie(name string) (*http.Cookie, error) {
+	return r.verifiedCookie(name, verifyCookieValue)
+}
+
+// EncryptedCookie returns the named cookie provided in the request, with its value decrypted by
+// any key of CookieEncryptionKeys. If the cookie is not found, http.ErrNoCookie is returned.
+// If the cookie cannot be decrypted, ErrInvalidCookie is returned.
+func (r *Request) EncryptedCookie(name string) (*http.Cookie, error) {
+	return r.verifiedCookie(name, decryptCookieValue)
+}
+
+func (r *Request) verifiedCookie(name string, verify func(name, value string) (string, error)) (*http.Cookie, error) {
+	err := http.ErrNoCookie
+	// there may be several cookies with the same name but different paths
+	for _, cookie := range r.Request.Cookies() {
+		if cookie.Name != name {
+			continue
+		}
+		var value string
+		if value, err = verify(name, cookie.Value); err == nil {
+			cookie.Value = value
+			return cookie, nil
+		}
+	}
+	return nil, err
+}
+
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	// the name is authenticated as well so that values cannot be swapped between cookies
+	mac.Write([]byte(name + "=" + value))
+	return mac.Sum(nil)
+}
+
+func signCookieValue(name, value string) (string, error) {
+	if len(CookieSigningKeys) == 0 {
+		return "", errNoCookieKey
+	}
+	mac := cookieMAC(CookieSigningKeys[0], name, value)
+	return cookieEncoding.EncodeToString([]byte(value)) + "." + cookieEncoding.EncodeToString(mac), nil
+}
+
+func verifyCookieValue(name, signed string) (string, error) {
+	if len(CookieSigningKeys) == 0 {
+		return "", errNoCookieKey
+	}
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	value, err := cookieEncoding.DecodeString(signed[:i])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	mac, err := cookieEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range CookieSigningKeys {
+		if hmac.Equal(mac, cookieMAC(key, name, string(value))) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptCookieValue(name, value string) (string, error) {
+	if len(CookieEncryptionKeys) == 0 {
+		return "", errNoCookieKey
+	}
+	aead, err := cookieAEAD(CookieEncryptionKeys[0])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	// the name is used as additional data so that values cannot be swapped between cookies
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return cookieEncoding.EncodeToString(sealed), nil
+}
+
+func decryptCookieValue(name, encrypted string) (string, error) {
+	if len(CookieEncryptionKeys) == 0 {
+		return "", errNoCookieKey
+	}
+	sealed, err := cookieEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range CookieEncryptionKeys {
+		aead, err := cookieAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
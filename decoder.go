@@ -0,0 +1,130 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decoder decodes request bodies of a media type into bindable objects.
+type Decoder interface {
+	// Decode decodes body, the request body of req, into v.
+	Decode(req *Request, body io.Reader, v any) error
+}
+
+// DecoderFunc is an adapter to allow the use of ordinary functions as Decoder.
+type DecoderFunc func(req *Request, body io.Reader, v any) error
+
+// Decode calls f(req, body, v).
+func (f DecoderFunc) Decode(req *Request, body io.Reader, v any) error {
+	return f(req, body, v)
+}
+
+// builtinDecoder is a Decoder built in DefaultRequestBinder,
+// which DefaultRequestBinder.BindBody dispatches to its own methods to apply its options.
+type builtinDecoder int
+
+const (
+	jsonDecoder builtinDecoder = iota
+	xmlDecoder
+	formDecoder
+)
+
+// Decode decodes body with the built-in decoder of a DefaultRequestBinder without options.
+func (d builtinDecoder) Decode(req *Request, body io.Reader, v any) error {
+	return new(DefaultRequestBinder).decode(d, req, body, v)
+}
+
+var decoders = struct {
+	sync.RWMutex
+	m map[string]Decoder
+}{m: map[string]Decoder{
+	MIMEApplicationJSON: jsonDecoder,
+	MIMEApplicationXML:  xmlDecoder,
+	MIMETextXML:         xmlDecoder,
+	MIMEApplicationForm: formDecoder,
+	MIMEMultipartForm:   formDecoder,
+	// patches are applied to the existing value of the bindable object
+	MIMEApplicationMergePatchJSON: patchDecoder(ApplyMergePatch),
+	MIMEApplicationJSONPatchJSON:  patchDecoder(ApplyJSONPatch),
+}}
+
+// patchDecoder is a Decoder which applies the patch document in the body to the bindable object.
+type patchDecoder func(target any, patch []byte) ([]string, error)
+
+// Decode applies the patch document in body to v.
+func (apply patchDecoder) Decode(_ *Request, body io.Reader, v any) error {
+	patch, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	_, err = apply(v, patch)
+	return err
+}
+
+// RegisterDecoder registers decoder for request bodies of mediaType, which is used by DefaultRequestBinder.BindBody.
+// It replaces the decoder previously registered for mediaType, including the built-in decoders of
+// "application/json", "application/xml", "text/xml", "application/x-www-form-urlencoded" and "multipart/form-data",
+// and those of "application/merge-patch+json" and "application/json-patch+json", which apply the patch document
+// to the existing value of the bindable object.
+// A nil decoder removes the registration.
+//
+// mediaType may be an exact media type such as "application/cbor", or a wildcard such as "application/*" or "*/*".
+// The decoder of a "Content-Type" is looked up in the following order: 1) the exact media type; 2) the media type
+// of a structured syntax suffix, i.e. "application/json" for "+json" and "application/xml" for "+xml", e.g. of
+// "application/vnd.api+json"; 3) the wildcard of the type, e.g. "application/*"; 4) "*/*".
+func RegisterDecoder(mediaType string, decoder Decoder) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	decoders.Lock()
+	defer decoders.Unlock()
+	if decoder == nil {
+		delete(decoders.m, mediaType)
+		return
+	}
+	decoders.m[mediaType] = decoder
+}
+
+// lookupDecoder returns the decoder registered for contentType, and false if there is none.
+func lookupDecoder(contentType string) (Decoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	candidates := []string{mediaType}
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		switch subtype[i+1:] {
+		case "json":
+			candidates = append(candidates, MIMEApplicationJSON)
+		case "xml":
+			candidates = append(candidates, MIMEApplicationXML)
+		}
+	}
+	candidates = append(candidates, typ+"/*", "*/*")
+
+	decoders.RLock()
+	defer decoders.RUnlock()
+	for _, candidate := range candidates {
+		if decoder, ok := decoders.m[candidate]; ok {
+			return decoder, true
+		}
+	}
+	return nil, false
+}
+
+// decodeError converts an error of a registered decoder into an HTTPError, unless it is already one
+// or a binding error.
+func decodeError(err error) error {
+	switch err.(type) {
+	case *HTTPError, *BindingError, BindingErrors:
+		return err
+	}
+	if errors.Is(err, ErrStatusRequestEntityTooLarge) {
+		return ErrStatusRequestEntityTooLarge
+	}
+	return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+}
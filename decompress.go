@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultMaxDecompressedBytes = 32 << 20 // 32 MB
+
+// Decompress returns a middleware that decompresses request bodies according to the "Content-Encoding" header.
+// See: Request.DecompressBody
+func Decompress() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HandlerFunc(func(req *Request, res *Responder) error {
+			if err := req.DecompressBody(); err != nil {
+				return err
+			}
+			return H(next)(req, res)
+		})
+	}
+}
+
+// DecompressBody replaces the request body with its decompressed content according to the "Content-Encoding" header.
+// The "gzip", "x-gzip" and "deflate" codings are supported, and ErrUnsupportedMediaType is returned for any other.
+// The header is then removed, so calling DecompressBody again has no effect.
+//
+// ErrStatusRequestEntityTooLarge is returned when reading the body if the decompressed content
+// exceeds BodyLimits.MaxDecompressedBytes of the request.
+func (r *Request) DecompressBody() error {
+	var codings []string
+	for _, value := range r.Request.Header.Values(HeaderContentEncoding) {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			switch coding {
+			case "", "identity":
+				continue
+			case "gzip", "x-gzip", "deflate":
+				codings = append(codings, coding)
+			default:
+				return ErrUnsupportedMediaType
+			}
+		}
+	}
+	if len(codings) == 0 {
+		r.Request.Header.Del(HeaderContentEncoding)
+		return nil
+	}
+
+	r.rewindBody()
+	body := r.Request.Body
+	if body == nil || body == http.NoBody {
+		return nil
+	}
+	var reader io.Reader = body
+	// codings are listed in the order they were applied
+	for i := len(codings) - 1; i >= 0; i-- {
+		switch codings[i] {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(reader)
+			if err != nil {
+				return decompressError(err)
+			}
+			reader = gz
+		case "deflate":
+			zr, err := newDeflateReader(reader)
+			if err != nil {
+				return decompressError(err)
+			}
+			reader = zr
+		}
+	}
+	reader = &limitedBody{ReadCloser: io.NopCloser(reader), limit: r.bodyLimits().MaxDecompressedBytes}
+
+	r.Request.Header.Del(HeaderContentEncoding)
+	r.Request.ContentLength = -1
+	if r.body != nil {
+		// the buffered body is replaced with its decompressed content
+		buf := new(bodyBuffer)
+		err := buf.readFrom(reader, r.bodyLimits().MaxBufferMemory)
+		r.body.close()
+		r.body = buf
+		r.Request.ContentLength = buf.size
+		r.rewindBody()
+		if err != nil {
+			return decompressError(err)
+		}
+		return nil
+	}
+	r.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, body}
+	return nil
+}
+
+// decompressError converts an error of decompressing the request body into an HTTPError.
+func decompressError(err error) error {
+	if err = bodyError(err); err == ErrStatusRequestEntityTooLarge {
+		return err
+	}
+	return WrapHTTPError(err, http.StatusBadRequest, err.Error())
+}
+
+// newDeflateReader returns a reader of the "deflate" coding, which is the zlib format.
+// Raw deflate data, which some clients send instead, is supported as well.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -24,6 +24,8 @@ type HTTPErrorHandlerFunc func(req *Request, res *Responder, err error)
 
 // HandleHTTPError returns the default HTTPErrorHandler used.
 // If expose is true, returned response will be the internal error message.
+// BindingErrors are rendered as JSON of the form {"errors": {"field": "message"}, "request_id": "id"},
+// where the messages of errors not caused by a particular field, e.g. syntax errors, are keyed "_body".
 func HandleHTTPError(expose bool) HTTPErrorHandlerFunc {
 	return func(req *Request, res *Responder, err error) {
 		if res.Committed {
@@ -34,27 +36,54 @@ func HandleHTTPError(expose bool) HTTPErrorHandlerFunc {
 			Code:    http.StatusInternalServerError,
 			Message: http.StatusText(http.StatusInternalServerError),
 		}
-		errors.As(err, &e)
+		var be *BindingError
+		var bes BindingErrors
+		switch {
+		case errors.As(err, &bes) && len(bes) > 0:
+			e = bes.httpError()
+		case errors.As(err, &be) && be.HTTPError != nil:
+			e = be.HTTPError
+		default:
+			errors.As(err, &e)
+		}
 
 		res.Status(e.Code) // affects the code in middlewares where after H is called
 
+		message := e.Message
 		if expose {
-			e.Message = err.Error()
+			message = err.Error()
+		}
+		if id := req.ID(); id != "" {
+			message += " (request id: " + id + ")"
 		}
 
 		var resErr error
 		if req.Method == http.MethodHead {
 			resErr = res.NoContent()
+		} else if len(bes) > 0 {
+			// binding errors are rendered as a map of field to message, along with the request ID
+			resErr = res.JSON(struct {
+				Errors    map[string]string `json:"errors"`
+				RequestID string            `json:"request_id,omitempty"`
+			}{bes.messages(), req.ID()}, "")
 		} else {
-			resErr = res.String(e.Message)
+			resErr = res.String(message)
 		}
 
 		if resErr != nil {
-			Logger.Println(resErr) // rare error case
+			logRequest(req.ID(), resErr) // rare error case
 		}
 	}
 }
 
+// logRequest logs v with Logger, prefixed with the request ID if there is one.
+func logRequest(id string, v ...any) {
+	if id != "" {
+		v = append([]any{"request_id=" + id}, v...)
+	}
+	Logger.Println(v...)
+}
+
 type contextKey string
 
 var (
@@ -78,6 +107,7 @@ func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		req = NewRequest(r)
 		req.SetValue(requestKey, req)
+		defer req.release()
 	}
 	res, ok := w.(*Responder)
 	if !ok {
@@ -96,6 +126,7 @@ func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // It returns the error returned by the handler for the caller (typically a middleware) to handle it.
 func H(handler http.Handler) HandlerFunc {
 	return func(req *Request, res *Responder) error {
+		req.rewindBody()
 		handler.ServeHTTP(res, req.Request)
 		err, ok := req.GetValue(errorKey).(error)
 		if ok {
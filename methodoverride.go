@@ -0,0 +1,102 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideGetter returns the method requested to override the method of req,
+// or an empty string if there is none.
+type MethodOverrideGetter func(req *Request) string
+
+// MethodOverrideConfig configures the MethodOverrideWithConfig middleware.
+type MethodOverrideConfig struct {
+	// Getters are tried in order to get the overriding method.
+	// Defaults to the "X-HTTP-Method-Override" header, the "_method" form field of URL-encoded forms
+	// and the "_method" query param.
+	Getters []MethodOverrideGetter
+	// AllowedMethods are the methods a request can be overridden with. Defaults to PUT, PATCH and DELETE.
+	AllowedMethods []string
+}
+
+// MethodFromHeader returns a MethodOverrideGetter that gets the method from the provided header.
+func MethodFromHeader(header string) MethodOverrideGetter {
+	return func(req *Request) string {
+		return req.Header.Get(header)
+	}
+}
+
+// MethodFromForm returns a MethodOverrideGetter that gets the method from the provided form field of the request body.
+// Multipart forms are parsed as a whole, including their files, so they cannot be streamed with
+// Request.StreamMultipart afterwards.
+func MethodFromForm(param string) MethodOverrideGetter {
+	return methodFromForm(param, true)
+}
+
+// methodFromForm returns a MethodOverrideGetter that gets the method from the provided form field of URL-encoded
+// request bodies, and of multipart request bodies as well if multipart is true.
+func methodFromForm(param string, multipart bool) MethodOverrideGetter {
+	return func(req *Request) string {
+		ctype := req.Header.Get(HeaderContentType)
+		if !strings.HasPrefix(ctype, MIMEApplicationForm) && !(multipart && strings.HasPrefix(ctype, MIMEMultipartForm)) {
+			return ""
+		}
+		if _, err := req.FormParams(); err != nil {
+			return ""
+		}
+		return req.PostForm.Get(param)
+	}
+}
+
+// MethodFromQuery returns a MethodOverrideGetter that gets the method from the provided query param.
+func MethodFromQuery(param string) MethodOverrideGetter {
+	return func(req *Request) string {
+		return req.QueryParam(param)
+	}
+}
+
+// MethodOverride returns a middleware that lets POST requests override their method.
+// See: MethodOverrideWithConfig
+func MethodOverride() func(next http.Handler) http.Handler {
+	return MethodOverrideWithConfig(MethodOverrideConfig{})
+}
+
+// MethodOverrideWithConfig returns a middleware that lets POST requests override their method
+// with one of config.AllowedMethods, e.g. for HTML forms to send PUT, PATCH and DELETE requests.
+// Requests with any other method, or overriding with a method that is not allowed, are left unchanged.
+//
+// The method is overridden before calling the wrapped handler, so the middleware must be used
+// before routing for the router to see the overriding method.
+func MethodOverrideWithConfig(config MethodOverrideConfig) func(next http.Handler) http.Handler {
+	if len(config.Getters) == 0 {
+		config.Getters = []MethodOverrideGetter{
+			MethodFromHeader(HeaderXHTTPMethodOverride),
+			// multipart forms are not parsed, so that they can still be streamed
+			methodFromForm("_method", false),
+			MethodFromQuery("_method"),
+		}
+	}
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	return func(next http.Handler) http.Handler {
+		return HandlerFunc(func(req *Request, res *Responder) error {
+			if req.Method == http.MethodPost {
+				for _, getter := range config.Getters {
+					method := strings.ToUpper(strings.TrimSpace(getter(req)))
+					if method == "" {
+						continue
+					}
+					for _, allowed := range config.AllowedMethods {
+						if strings.EqualFold(method, allowed) {
+							req.Method = method
+							break
+						}
+					}
+					break
+				}
+			}
+			return H(next)(req, res)
+		})
+	}
+}
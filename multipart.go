@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes considered by http.DetectContentType.
+const sniffLen = 512
+
+// StreamConfig configures how Request.StreamMultipartWithConfig handles files.
+type StreamConfig struct {
+	// MaxFileSize is the maximum number of bytes of each file. Zero means unlimited.
+	MaxFileSize int64
+	// AllowedTypes lists the media types allowed for files, which are detected by sniffing
+	// their content with http.DetectContentType. Wildcards such as "image/*" are supported.
+	// Empty means every media type is allowed.
+	AllowedTypes []string
+	// Hash creates the hash used to compute the checksum of each part. Defaults to sha256.New.
+	Hash func() hash.Hash
+}
+
+// Part is a single part of a streamed multipart body.
+// Reading from a Part enforces the limits of the StreamConfig and updates its checksum.
+type Part struct {
+	*multipart.Part
+
+	// ContentType is the media type of a file detected by sniffing its content.
+	// It is empty for non-file fields.
+	ContentType string
+
+	reader  io.Reader
+	hash    hash.Hash
+	size    int64
+	maxSize int64
+	err     error
+}
+
+// IsFile returns true if the part is a file otherwise false.
+func (p *Part) IsFile() bool {
+	return p.FileName() != ""
+}
+
+// Read reads the body of the part.
+// ErrStatusRequestEntityTooLarge is returned if a file exceeds StreamConfig.MaxFileSize.
+func (p *Part) Read(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	n, err := p.reader.Read(b)
+	p.size += int64(n)
+	if p.maxSize > 0 && p.size > p.maxSize {
+		n -= int(p.size - p.maxSize)
+		p.size = p.maxSize
+		p.err = ErrStatusRequestEntityTooLarge
+		err = p.err
+	}
+	p.hash.Write(b[:n])
+	return n, err
+}
+
+// Size returns the number of bytes read from the part so far.
+func (p *Part) Size() int64 {
+	return p.size
+}
+
+// Sum returns the checksum of the bytes read from the part so far.
+func (p *Part) Sum() []byte {
+	return p.hash.Sum(nil)
+}
+
+// UploadedFile is a file of a multipart form bound to a struct field with a "form" tag.
+type UploadedFile struct {
+	*multipart.FileHeader
+
+	// ContentType is the media type of the file detected by sniffing its content.
+	ContentType string
+}
+
+// newUploadedFile creates an UploadedFile of fh, sniffing the media type of its content.
+func newUploadedFile(fh *multipart.FileHeader) (*UploadedFile, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return &UploadedFile{FileHeader: fh, ContentType: http.DetectContentType(buf[:n])}, nil
+}
+
+// StreamMultipart calls fn for every part of a multipart body as it arrives,
+// without buffering the parts in memory or on disk.
+// See: Request.StreamMultipartWithConfig
+func (r *Request) StreamMultipart(fn func(part *Part) error) error {
+	return r.StreamMultipartWithConfig(StreamConfig{}, fn)
+}
+
+// StreamMultipartWithConfig calls fn for every part of a multipart body as it arrives,
+// without buffering the parts in memory or on disk. Any part not fully read by fn is discarded.
+//
+// The number of parts and files are limited according to the BodyLimits of the request.
+// ErrUnsupportedMediaType is returned if the media type of a file is not allowed by config.
+func (r *Request) StreamMultipartWithConfig(config StreamConfig, fn func(part *Part) error) error {
+	if config.Hash == nil {
+		config.Hash = sha256.New
+	}
+	r.rewindBody()
+	mr, err := r.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+	limits := r.bodyLimits()
+
+	var parts, files int
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return bodyError(err)
+		}
+		parts++
+		if limits.MaxParts > 0 && parts > limits.MaxParts {
+			return ErrStatusRequestEntityTooLarge
+		}
+
+		part := &Part{Part: p, reader: p, hash: config.Hash()}
+		if part.IsFile() {
+			files++
+			if limits.MaxFiles > 0 && files > limits.MaxFiles {
+				return ErrStatusRequestEntityTooLarge
+			}
+			part.maxSize = config.MaxFileSize
+			if err = part.sniff(config.AllowedTypes); err != nil {
+				return err
+			}
+		}
+		if err = fn(part); err != nil {
+			return err
+		}
+	}
+}
+
+// sniff detects the media type of the part and checks it against allowed.
+// The sniffed bytes are read again by the next calls to Read.
+func (p *Part) sniff(allowed []string) error {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(p.Part, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return bodyError(err)
+	}
+	buf = buf[:n]
+	p.ContentType = http.DetectContentType(buf)
+	if !matchMediaTypes(allowed, p.ContentType) {
+		return ErrUnsupportedMediaType
+	}
+	p.reader = io.MultiReader(bytes.NewReader(buf), p.Part)
+	return nil
+}
+
+// matchMediaTypes reports whether mediaType matches any of patterns, or true if patterns is empty.
+// Patterns may be exact media types or wildcards such as "image/*" and "*/*". Parameters are ignored.
+func matchMediaTypes(patterns []string, mediaType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	typ, _, _ := strings.Cut(mediaType, "/")
+	for _, pattern := range patterns {
+		pattern, _, _ = strings.Cut(pattern, ";")
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "*/*" || strings.EqualFold(pattern, mediaType) {
+			return true
+		}
+		if ptyp, psub, _ := strings.Cut(pattern, "/"); psub == "*" && strings.EqualFold(ptyp, typ) {
+			return true
+		}
+	}
+	return false
+}
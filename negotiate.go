@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptSpec is a single element of an Accept-* header.
+type acceptSpec struct {
+	value string
+	q     float64
+	index int
+}
+
+// parseAccept parses the comma separated elements of an Accept-* header together with their q-values.
+// Elements with a malformed q-value are ignored.
+// See: https://www.rfc-editor.org/rfc/rfc9110#section-12.4.2
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, element := range strings.Split(header, ",") {
+		params := strings.Split(element, ";")
+		value := strings.TrimSpace(params[0])
+		if value == "" {
+			continue
+		}
+		q, ok := 1.0, true
+		for _, param := range params[1:] {
+			k, v, _ := strings.Cut(param, "=")
+			if !strings.EqualFold(strings.TrimSpace(k), "q") {
+				continue
+			}
+			q, ok = parseQValue(strings.TrimSpace(v))
+		}
+		if !ok {
+			continue
+		}
+		specs = append(specs, acceptSpec{value: value, q: q, index: len(specs)})
+	}
+	return specs
+}
+
+// parseQValue parses a qvalue, which is a number between 0 and 1 with up to three decimal digits.
+func parseQValue(s string) (float64, bool) {
+	if s == "" || len(s) > 5 || (s[0] != '0' && s[0] != '1') {
+		return 0, false
+	}
+	q, err := strconv.ParseFloat(s, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0, false
+	}
+	return q, true
+}
+
+// negotiate returns the offer preferred by specs, or an empty string if none of the offers is acceptable.
+//
+// match reports how specifically a header value matches an offer, or a negative number if it does not.
+// For each offer the q-value of the most specific matching element is used. Offers are then ranked
+// by q-value, specificity, position of the element in the header and finally position in offers.
+func negotiate(specs []acceptSpec, offers []string, match func(offer, value string) int) string {
+	best, bestQ, bestS, bestIndex := "", 0.0, -1, 0
+	for _, offer := range offers {
+		q, s, index := 0.0, -1, 0
+		for _, spec := range specs {
+			ms := match(offer, spec.value)
+			if ms < 0 {
+				continue
+			}
+			if ms > s || (ms == s && spec.q > q) {
+				q, s, index = spec.q, ms, spec.index
+			}
+		}
+		if s < 0 || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && (s > bestS || (s == bestS && index < bestIndex))) {
+			best, bestQ, bestS, bestIndex = offer, q, s, index
+		}
+	}
+	return best
+}
+
+func (r *Request) acceptHeader(name string) (string, bool) {
+	values := r.Request.Header.Values(name)
+	return strings.Join(values, ","), len(values) > 0
+}
+
+// AcceptsEncodings returns the best content coding among offers according to the "Accept-Encoding" header,
+// or an empty string if none of them is acceptable. Every offer is acceptable if the header is absent.
+//
+// As per RFC 9110, "identity" is always acceptable unless explicitly excluded by "identity;q=0" or "*;q=0".
+func (r *Request) AcceptsEncodings(offers ...string) string {
+	header, ok := r.acceptHeader(HeaderAcceptEncoding)
+	if !ok {
+		return firstOffer(offers)
+	}
+	specs := parseAccept(header)
+	identity := true
+	for _, spec := range specs {
+		if strings.EqualFold(spec.value, "identity") || spec.value == "*" {
+			identity = false
+			break
+		}
+	}
+	if identity {
+		specs = append(specs, acceptSpec{value: "identity", q: 0.001, index: len(specs)})
+	}
+	return negotiate(specs, offers, func(offer, value string) int {
+		switch {
+		case strings.EqualFold(offer, value):
+			return 1
+		case value == "*":
+			return 0
+		}
+		return -1
+	})
+}
+
+// AcceptsCharsets returns the best charset among offers according to the "Accept-Charset" header,
+// or an empty string if none of them is acceptable. Every offer is acceptable if the header is absent.
+func (r *Request) AcceptsCharsets(offers ...string) string {
+	header, ok := r.acceptHeader(HeaderAcceptCharset)
+	if !ok {
+		return firstOffer(offers)
+	}
+	return negotiate(parseAccept(header), offers, func(offer, value string) int {
+		switch {
+		case strings.EqualFold(offer, value):
+			return 1
+		case value == "*":
+			return 0
+		}
+		return -1
+	})
+}
+
+// AcceptsLanguages returns the best language tag among offers according to the "Accept-Language" header,
+// or an empty string if none of them is acceptable. Every offer is acceptable if the header is absent.
+//
+// Language ranges are matched against offers with both basic filtering and lookup as defined in RFC 4647,
+// so the range "zh-Hant" matches the offer "zh-Hant-TW" and the range "zh-Hant-TW" matches the offer "zh-Hant".
+// Exact matches are preferred over filtering matches, which are preferred over lookup matches.
+// See: https://www.rfc-editor.org/rfc/rfc4647
+func (r *Request) AcceptsLanguages(offers ...string) string {
+	header, ok := r.acceptHeader(HeaderAcceptLanguage)
+	if !ok {
+		return firstOffer(offers)
+	}
+	return negotiate(parseAccept(header), offers, matchLanguage)
+}
+
+// matchLanguage reports how specifically the language range rng matches the language tag.
+func matchLanguage(tag, rng string) int {
+	switch {
+	case rng == "*":
+		return 0
+	case strings.EqualFold(tag, rng):
+		return 3 << 8
+	case len(tag) > len(rng) && tag[len(rng)] == '-' && strings.EqualFold(tag[:len(rng)], rng):
+		// basic filtering: the range is a prefix of the tag
+		return 2<<8 + strings.Count(rng, "-")
+	case lookupLanguage(tag, rng):
+		return 1<<8 + strings.Count(tag, "-")
+	}
+	return -1
+}
+
+// lookupLanguage reports whether tag is found by progressively truncating rng from the end.
+// See: https://www.rfc-editor.org/rfc/rfc4647#section-3.4
+func lookupLanguage(tag, rng string) bool {
+	for {
+		i := strings.LastIndexByte(rng, '-')
+		if i < 0 {
+			return false
+		}
+		rng = rng[:i]
+		// a singleton subtag is removed together with the subtag that follows it
+		if j := strings.LastIndexByte(rng, '-'); j >= 0 && len(rng)-j == 2 {
+			rng = rng[:j]
+		}
+		if strings.EqualFold(tag, rng) {
+			return true
+		}
+	}
+}
+
+func firstOffer(offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	return offers[0]
+}
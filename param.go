@@ -0,0 +1,40 @@
+package httpx
+
+import "net/http"
+
+// PathParamFunc returns the value of the named path parameter of r,
+// or an empty string if there is no such parameter.
+type PathParamFunc func(r *http.Request, name string) string
+
+// PathParamExtractor is used to extract path parameters from requests.
+// Set this global variable to the adapter for your router. Defaults to PathValueParam.
+//
+// Routers that expose path parameters through a function, such as chi.URLParam,
+// can be used directly, e.g. httpx.PathParamExtractor = chi.URLParam.
+// Routers that expose them as a map, such as mux.Vars, can be adapted with VarsPathParam.
+var PathParamExtractor PathParamFunc = PathValueParam
+
+// PathValueParam returns the path parameter matched by http.ServeMux as of Go 1.22,
+// i.e. http.Request.PathValue. It always returns an empty string on earlier Go versions.
+func PathValueParam(r *http.Request, name string) string {
+	if pv, ok := any(r).(interface{ PathValue(name string) string }); ok {
+		return pv.PathValue(name)
+	}
+	return ""
+}
+
+// VarsPathParam adapts a function that returns all path parameters of a request as a map,
+// such as mux.Vars, into a PathParamFunc.
+func VarsPathParam(vars func(r *http.Request) map[string]string) PathParamFunc {
+	return func(r *http.Request, name string) string {
+		return vars(r)[name]
+	}
+}
+
+// Param returns the path parameter for the provided name using PathParamExtractor.
+func (r *Request) Param(name string) string {
+	if PathParamExtractor == nil {
+		return ""
+	}
+	return PathParamExtractor(r.Request, name)
+}
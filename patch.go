@@ -0,0 +1,572 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchOperation is an operation of a JSON Patch document.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// BindPatch applies the patch document in the request body to target, an existing object such as a pointer to
+// a struct or to a map[string]any, according to the "Content-Type" header, which is either
+// "application/merge-patch+json" or "application/json-patch+json". It returns the JSON Pointers of the fields
+// touched by the patch, e.g. "/address/city". ErrUnsupportedMediaType is returned for any other media type.
+// See: ApplyMergePatch, ApplyJSONPatch
+func (r *Request) BindPatch(target any) ([]string, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Request.Header.Get(HeaderContentType))
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+	var apply func(target any, patch []byte) ([]string, error)
+	switch mediaType {
+	case MIMEApplicationMergePatchJSON:
+		apply = ApplyMergePatch
+	case MIMEApplicationJSONPatchJSON:
+		apply = ApplyJSONPatch
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+	if err = r.DecompressBody(); err != nil {
+		return nil, err
+	}
+	patch, err := r.BodyBytes()
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return apply(target, patch)
+}
+
+// ApplyMergePatch applies a JSON Merge Patch document to target, which is a pointer to a struct, a map or any other
+// value encoded to and decoded from JSON. It returns the JSON Pointers of the members touched by the patch.
+// Fields which are not encoded to JSON, i.e. unexported fields and fields tagged `json:"-"`, keep their values.
+//
+// An HTTPError is returned with status code 400 if the patch is malformed,
+// and with status code 422 if the patched document cannot be decoded into target.
+// See: https://www.rfc-editor.org/rfc/rfc7396
+func ApplyMergePatch(target any, patch []byte) ([]string, error) {
+	var p any
+	if err := decodeJSONValue(patch, &p); err != nil {
+		return nil, WrapHTTPError(err, http.StatusBadRequest, "malformed merge patch: "+err.Error())
+	}
+	doc, err := encodeJSONDocument(target)
+	if err != nil {
+		return nil, err
+	}
+	var touched []string
+	doc = mergePatch(doc, p, "", &touched)
+	if err = decodeJSONDocument(target, doc); err != nil {
+		return nil, err
+	}
+	return touched, nil
+}
+
+// mergePatch applies patch to target, and appends the pointers of the touched members to touched.
+func mergePatch(target, patch any, path string, touched *[]string) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		*touched = append(*touched, path)
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		memberPath := path + "/" + escapeJSONPointer(k)
+		if p[k] == nil {
+			delete(t, k)
+			*touched = append(*touched, memberPath)
+			continue
+		}
+		t[k] = mergePatch(t[k], p[k], memberPath, touched)
+	}
+	return t
+}
+
+// ApplyJSONPatch applies a JSON Patch document to target, which is a pointer to a struct, a map or any other
+// value encoded to and decoded from JSON. It returns the JSON Pointers of the locations touched by the operations,
+// including the "from" location of "move" operations. Either all operations are applied or none of them.
+// Fields which are not encoded to JSON, i.e. unexported fields and fields tagged `json:"-"`, keep their values.
+//
+// An HTTPError is returned with status code 400 if the patch is malformed, with status code 409 if a "test"
+// operation fails, and with status code 422 if a location does not exist or the patched document cannot be
+// decoded into target.
+// See: https://www.rfc-editor.org/rfc/rfc6902
+func ApplyJSONPatch(target any, patch []byte) ([]string, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, WrapHTTPError(err, http.StatusBadRequest, "malformed json patch: "+err.Error())
+	}
+	doc, err := encodeJSONDocument(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var touched []string
+	seen := make(map[string]bool)
+	touch := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			touched = append(touched, path)
+		}
+	}
+	for i, op := range ops {
+		if doc, err = applyJSONPatchOperation(doc, op); err != nil {
+			var he *HTTPError
+			if errors.As(err, &he) {
+				return nil, WrapHTTPError(err, he.Code, fmt.Sprintf("json patch operation %d: %s", i, he.Message))
+			}
+			return nil, err
+		}
+		switch op.Op {
+		case "test":
+		case "move":
+			touch(*op.From)
+			touch(*op.Path)
+		default:
+			touch(*op.Path)
+		}
+	}
+	if err = decodeJSONDocument(target, doc); err != nil {
+		return nil, err
+	}
+	return touched, nil
+}
+
+func applyJSONPatchOperation(doc any, op jsonPatchOperation) (any, error) {
+	if op.Path == nil {
+		return nil, NewHTTPError(http.StatusBadRequest, `missing "path"`)
+	}
+	path, err := parseJSONPointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var from []string
+	switch op.Op {
+	case "move", "copy":
+		if op.From == nil {
+			return nil, NewHTTPError(http.StatusBadRequest, `missing "from"`)
+		}
+		if from, err = parseJSONPointer(*op.From); err != nil {
+			return nil, err
+		}
+	}
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, NewHTTPError(http.StatusBadRequest, `missing "value"`)
+		}
+		if err = decodeJSONValue(op.Value, &value); err != nil {
+			return nil, WrapHTTPError(err, http.StatusBadRequest, "malformed value: "+err.Error())
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return jsonPointerAdd(doc, path, value, false)
+	case "remove":
+		doc, _, err = jsonPointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		return jsonPointerAdd(doc, path, value, true)
+	case "move":
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("cannot move %q into its own child %q", *op.From, *op.Path))
+		}
+		if doc, value, err = jsonPointerRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, value, false)
+	case "copy":
+		if value, err = jsonPointerGet(doc, from); err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, copyJSONValue(value), false)
+	case "test":
+		actual, err := jsonPointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSONValues(actual, value) {
+			return nil, NewHTTPError(http.StatusConflict, fmt.Sprintf("test failed at %q", *op.Path))
+		}
+		return doc, nil
+	}
+	return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown op %q", op.Op))
+}
+
+// parseJSONPointer parses a JSON Pointer into its reference tokens.
+// See: https://www.rfc-editor.org/rfc/rfc6901
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid json pointer %q", pointer))
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func jsonPointerError(tokens []string) error {
+	pointer := ""
+	for _, token := range tokens {
+		pointer += "/" + escapeJSONPointer(token)
+	}
+	return NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("path %q does not exist", pointer))
+}
+
+// jsonArrayIndex parses token as an index of an array, which must be less than n.
+func jsonArrayIndex(token string, n int) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= n {
+		return 0, false
+	}
+	return i, true
+}
+
+func jsonPointerGet(doc any, tokens []string) (any, error) {
+	for n, token := range tokens {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, jsonPointerError(tokens[:n+1])
+			}
+			doc = v
+		case []any:
+			i, ok := jsonArrayIndex(token, len(c))
+			if !ok {
+				return nil, jsonPointerError(tokens[:n+1])
+			}
+			doc = c[i]
+		default:
+			return nil, jsonPointerError(tokens[:n+1])
+		}
+	}
+	return doc, nil
+}
+
+// jsonPointerAdd adds value at tokens in doc, or replaces the existing value if replace is true,
+// and returns the new document.
+func jsonPointerAdd(doc any, tokens []string, value any, replace bool) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token, rest := tokens[0], tokens[1:]
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[token]
+		if len(rest) == 0 {
+			if replace && !ok {
+				return nil, jsonPointerError(tokens[:1])
+			}
+			c[token] = value
+			return c, nil
+		}
+		if !ok {
+			return nil, jsonPointerError(tokens[:1])
+		}
+		child, err := jsonPointerAdd(child, rest, value, replace)
+		if err != nil {
+			return nil, prefixJSONPointerError(err, token)
+		}
+		c[token] = child
+		return c, nil
+	case []any:
+		if len(rest) == 0 && !replace {
+			if token == "-" {
+				return append(c, value), nil
+			}
+			i, ok := jsonArrayIndex(token, len(c)+1)
+			if !ok {
+				return nil, jsonPointerError(tokens[:1])
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		i, ok := jsonArrayIndex(token, len(c))
+		if !ok {
+			return nil, jsonPointerError(tokens[:1])
+		}
+		if len(rest) == 0 {
+			c[i] = value
+			return c, nil
+		}
+		child, err := jsonPointerAdd(c[i], rest, value, replace)
+		if err != nil {
+			return nil, prefixJSONPointerError(err, token)
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, jsonPointerError(tokens[:1])
+}
+
+// jsonPointerRemove removes the value at tokens in doc, and returns the new document and the removed value.
+func jsonPointerRemove(doc any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, NewHTTPError(http.StatusUnprocessableEntity, "cannot remove the whole document")
+	}
+	token, rest := tokens[0], tokens[1:]
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[token]
+		if !ok {
+			return nil, nil, jsonPointerError(tokens[:1])
+		}
+		if len(rest) == 0 {
+			delete(c, token)
+			return c, child, nil
+		}
+		child, removed, err := jsonPointerRemove(child, rest)
+		if err != nil {
+			return nil, nil, prefixJSONPointerError(err, token)
+		}
+		c[token] = child
+		return c, removed, nil
+	case []any:
+		i, ok := jsonArrayIndex(token, len(c))
+		if !ok {
+			return nil, nil, jsonPointerError(tokens[:1])
+		}
+		if len(rest) == 0 {
+			removed := c[i]
+			return append(c[:i], c[i+1:]...), removed, nil
+		}
+		child, removed, err := jsonPointerRemove(c[i], rest)
+		if err != nil {
+			return nil, nil, prefixJSONPointerError(err, token)
+		}
+		c[i] = child
+		return c, removed, nil
+	}
+	return nil, nil, jsonPointerError(tokens[:1])
+}
+
+// prefixJSONPointerError prefixes the path in a "does not exist" error with token of the parent.
+func prefixJSONPointerError(err error, token string) error {
+	var he *HTTPError
+	if errors.As(err, &he) && strings.HasPrefix(he.Message, `path "/`) {
+		return NewHTTPError(he.Code, `path "/`+escapeJSONPointer(token)+strings.TrimPrefix(he.Message, `path "`))
+	}
+	return err
+}
+
+func copyJSONValue(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, e := range c {
+			m[k] = copyJSONValue(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(c))
+		for i, e := range c {
+			s[i] = copyJSONValue(e)
+		}
+		return s
+	}
+	return v
+}
+
+// equalJSONValues reports whether a and b are equal JSON values. Numbers are compared by their values.
+func equalJSONValues(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, e := range x {
+			if f, ok := y[k]; !ok || !equalJSONValues(e, f) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equalJSONValues(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	}
+	return a == b
+}
+
+// decodeJSONValue decodes exactly one JSON value from data, preserving numbers as json.Number.
+func decodeJSONValue(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// encodeJSONDocument encodes target into a generic JSON document.
+func encodeJSONDocument(target any) (any, error) {
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return nil, errors.New("patch target must be a non-nil pointer")
+	}
+	data, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err = decodeJSONValue(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decodeJSONDocument decodes a generic JSON document into target, replacing its value
+// except for the fields which are not encoded to JSON.
+func decodeJSONDocument(target any, doc any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	val := reflect.ValueOf(target).Elem()
+	patched := reflect.New(val.Type())
+	patched.Elem().Set(val)
+	resetJSONValue(patched.Elem(), doc)
+	if err = json.Unmarshal(data, patched.Interface()); err != nil {
+		return WrapHTTPError(err, http.StatusUnprocessableEntity, err.Error())
+	}
+	val.Set(patched.Elem())
+	return nil
+}
+
+// resetJSONValue prepares val, a copy of the value being patched, for doc to be decoded into it. Structs, and
+// copies of the structs pointed to, keep the fields which are not encoded to JSON, and the fields absent from doc,
+// e.g. removed by the patch, are zeroed. Any other value is zeroed, so that decoding neither merges into
+// nor modifies the maps, slices and pointers shared with the original value.
+func resetJSONValue(val reflect.Value, doc any) {
+	object, ok := doc.(map[string]any)
+	if !ok || !isJSONStruct(val.Type()) {
+		val.Set(reflect.Zero(val.Type()))
+		return
+	}
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return
+		}
+		val = copyPointee(val)
+	}
+	resetJSONFields(val, object)
+}
+
+// resetJSONFields resets the fields of struct val with the members of object. See: resetJSONValue
+func resetJSONFields(val reflect.Value, object map[string]any) {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _ := parseTag(tag)
+		f := val.Field(i)
+		if field.Anonymous && name == "" {
+			// fields of embedded structs are promoted
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				resetJSONFields(f, object)
+				continue
+			case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+				if field.IsExported() && !f.IsNil() {
+					resetJSONFields(copyPointee(f), object)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if member, ok := jsonMember(object, name); ok {
+			resetJSONValue(f, member)
+		} else {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
+// copyPointee sets pointer ptr to a copy of the value it points to, and returns the copy.
+func copyPointee(ptr reflect.Value) reflect.Value {
+	elem := reflect.New(ptr.Type().Elem())
+	elem.Elem().Set(ptr.Elem())
+	ptr.Set(elem)
+	return elem.Elem()
+}
+
+// isJSONStruct reports whether typ is a struct, or a pointer to a struct, decoded field by field.
+func isJSONStruct(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	ptr := reflect.PtrTo(typ)
+	return !ptr.Implements(jsonUnmarshalerType) && !ptr.Implements(textUnmarshalerType)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
@@ -1,15 +1,12 @@
 package httpx
 
 import (
-	"context"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-const defaultMaxMemory = 32 << 20 // 32 MB
-
 // RequestBinder is used to bind request body data to objects.
 // Set this global variable to your preferred binder once before
 // calling any Request.Bind. The default DefaultRequestBinder binds data
@@ -23,12 +20,19 @@ var RequestBinder interface {
 type Request struct {
 	*http.Request // inherit from http.Request
 
-	query url.Values
+	query  url.Values
+	limits *BodyLimits
+	body   *bodyBuffer
+	store  *valueStore
 }
 
 // NewRequest creates a new instance of Request.
+// The request body is limited according to DefaultBodyLimits.
 func NewRequest(r *http.Request) *Request {
-	return &Request{Request: r}
+	req := &Request{Request: r}
+	req.valueStore()
+	req.limitBody(DefaultBodyLimits.MaxBytes)
+	return req
 }
 
 // IsTLS returns true if HTTP connection is TLS otherwise false.
@@ -86,21 +90,46 @@ func (r *Request) QueryString() string {
 }
 
 // FormParams returns the form parameters as url.Values.
+// ErrStatusRequestEntityTooLarge is returned if the body exceeds the limits of the request.
 func (r *Request) FormParams() (url.Values, error) {
+	r.rewindBody()
 	if strings.HasPrefix(r.Header.Get(HeaderContentType), MIMEMultipartForm) {
-		if err := r.ParseMultipartForm(defaultMaxMemory); err != nil {
+		if err := r.parseMultipartForm(); err != nil {
 			return nil, err
 		}
 	} else {
 		if err := r.ParseForm(); err != nil {
-			return nil, err
+			return nil, bodyError(err)
 		}
 	}
 	return r.Form, nil
 }
 
+// postFormParams returns the form parameters of the request body, excluding those of the URL query
+// which FormParams includes as well.
+func (r *Request) postFormParams() (url.Values, error) {
+	if _, err := r.FormParams(); err != nil {
+		return nil, err
+	}
+	params := make(url.Values, len(r.Request.PostForm))
+	for key, values := range r.Request.PostForm {
+		params[key] = values
+	}
+	if form := r.Request.MultipartForm; form != nil {
+		for key, values := range form.Value {
+			if _, ok := params[key]; !ok {
+				params[key] = values
+			}
+		}
+	}
+	return params, nil
+}
+
 // FormFile returns the multipart form file for the provided name.
 func (r *Request) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := r.parseMultipartForm(); err != nil {
+		return nil, err
+	}
 	f, fh, err := r.Request.FormFile(name)
 	if err != nil {
 		return nil, err
@@ -111,28 +140,51 @@ func (r *Request) FormFile(name string) (*multipart.FileHeader, error) {
 
 // MultipartForm returns the multipart form.
 func (r *Request) MultipartForm() (*multipart.Form, error) {
-	err := r.Request.ParseMultipartForm(defaultMaxMemory)
+	err := r.parseMultipartForm()
 	return r.Request.MultipartForm, err
 }
 
-// SetValue sets a value with key to the underlying http.Request's context.Context.
-// The context can be retrieved using Request.Context().
+// SetValue sets a value with key to the value store of the request, which is stored once in the underlying
+// http.Request's context.Context. The store is safe for concurrent use and shared by every http.Request
+// derived from the request, so the value is visible to everyone holding any of them or their contexts.
+// The context can be retrieved using Request.Context(). See Key for typed access to values.
 func (r *Request) SetValue(key, val any) {
-	ctx := context.WithValue(r.Request.Context(), key, val)
-	r.Request = r.Request.WithContext(ctx)
+	r.valueStore().set(key, val)
 }
 
-// GetValue gets a value by key from the underlying http.Request's context.Context.
+// GetValue gets a value by key from the underlying http.Request's context.Context,
+// which includes the values set with Request.SetValue.
 // The context can be retrieved using Request.Context().
 func (r *Request) GetValue(key any) any {
+	r.valueStore()
 	return r.Request.Context().Value(key)
 }
 
 // Bind binds data from request body to v.
 // Immediately panic if RequestBinder is not set in advance.
-func (r *Request) Bind(v any) error {
+//
+// If opts are given, e.g. req.Bind(&v, httpx.FromBody, httpx.FromPath), the sources are bound in the given
+// order of precedence with BindWith of RequestBinder, which DefaultRequestBinder implements.
+// Immediately panic if RequestBinder does not implement BindWith.
+//
+// After binding, Validator.Validate or ContextValidator.ValidateContext of v, and of its nested fields,
+// is called. Errors other than HTTPError and BindingError are wrapped as HTTPError with status code 422.
+func (r *Request) Bind(v any, opts ...BindOption) error {
 	if RequestBinder == nil {
 		panic("undefined request binder")
 	}
-	return RequestBinder.Bind(r, v)
+	var err error
+	if len(opts) == 0 {
+		err = RequestBinder.Bind(r, v)
+	} else if binder, ok := RequestBinder.(interface {
+		BindWith(req *Request, v any, opts ...BindOption) error
+	}); ok {
+		err = binder.BindWith(r, v, opts...)
+	} else {
+		panic("request binder does not support bind options")
+	}
+	if err != nil {
+		return err
+	}
+	return callValidators(r.Context(), v)
 }
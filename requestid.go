@@ -0,0 +1,113 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+const defaultRequestIDMaxLength = 64
+
+var requestIDKey = NewKey[string]("request_id")
+
+// RequestIDConfig configures the RequestIDWithConfig middleware.
+type RequestIDConfig struct {
+	// Generator generates the ID of a request without a valid incoming ID. Defaults to generating UUIDv7.
+	Generator func() string
+	// MaxLength is the maximum length of an incoming ID. Defaults to 64.
+	MaxLength int
+}
+
+// RequestID returns a middleware that assigns an ID to every request.
+// See: RequestIDWithConfig
+func RequestID() func(next http.Handler) http.Handler {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig returns a middleware that assigns an ID to every request.
+//
+// The incoming "X-Request-ID" header, or else the "X-Correlation-ID" header, is used as the ID
+// if it is no longer than config.MaxLength and only consists of letters, digits and "-_.:+/=".
+// Otherwise a new ID is generated. The ID is set in the "X-Request-ID" response header and stored
+// in the request context, where it can be retrieved using Request.ID or RequestIDFromContext.
+func RequestIDWithConfig(config RequestIDConfig) func(next http.Handler) http.Handler {
+	if config.Generator == nil {
+		config.Generator = newUUIDv7
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = defaultRequestIDMaxLength
+	}
+	return func(next http.Handler) http.Handler {
+		return HandlerFunc(func(req *Request, res *Responder) error {
+			id := req.Header.Get(HeaderXRequestID)
+			if !validRequestID(id, config.MaxLength) {
+				id = req.Header.Get(HeaderXCorrelationID)
+			}
+			if !validRequestID(id, config.MaxLength) {
+				id = config.Generator()
+			}
+			requestIDKey.Set(req, id)
+			res.requestID = id
+			res.Header().Set(HeaderXRequestID, id)
+			return H(next)(req, res)
+		})
+	}
+}
+
+// ID returns the ID of the request assigned by the RequestID middleware,
+// or an empty string if the middleware is not used.
+func (r *Request) ID() string {
+	id, _ := requestIDKey.Get(r)
+	return id
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by the RequestID middleware,
+// or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := requestIDKey.FromContext(ctx)
+	return id
+}
+
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '+', c == '/', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newUUIDv7 generates a time-ordered UUID version 7.
+// See: https://www.rfc-editor.org/rfc/rfc9562#section-5.7
+func newUUIDv7() string {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		panic(err)
+	}
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ts[2:])
+	u[6] = 0x70 | u[6]&0x0f // version 7
+	u[8] = 0x80 | u[8]&0x3f // variant 10
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -22,6 +22,7 @@ var (
 type Responder struct {
 	beforeFuncs []func()
 	afterFuncs  []func()
+	requestID   string // set by the RequestID middleware for logging
 
 	Size       int64
 	Committed  bool
@@ -76,7 +77,7 @@ func (r *Responder) After(fn func()) {
 // used to send error codes.
 func (r *Responder) WriteHeader(code int) {
 	if r.Committed {
-		Logger.Println(errHeaderAlreadyCommitted)
+		logRequest(r.requestID, errHeaderAlreadyCommitted)
 		return
 	}
 	r.StatusCode = code
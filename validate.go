@@ -0,0 +1,502 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	regexpsCache sync.Map // map[string]*regexp.Regexp
+)
+
+// Validator is the interface implemented by types that can validate themselves.
+type Validator interface {
+	Validate() error
+}
+
+// ContextValidator is the interface implemented by types that can validate themselves with a context,
+// e.g. against a database. It takes precedence over Validator.
+type ContextValidator interface {
+	ValidateContext(ctx context.Context) error
+}
+
+// callValidators calls Validator.Validate or ContextValidator.ValidateContext of v and of its nested fields,
+// the innermost first, and stops at the first error. The method of an embedded field is not called if the struct
+// has the same method, i.e. the promoted method or one overriding it, so that it is called only once.
+func callValidators(ctx context.Context, v any) error {
+	err := walkValidators(ctx, reflect.ValueOf(v), make(map[visit]bool), nil)
+	if err == nil {
+		return nil
+	}
+	switch err.(type) {
+	case *HTTPError, *BindingError, BindingErrors:
+		return err
+	}
+	return WrapHTTPError(err, http.StatusUnprocessableEntity, err.Error())
+}
+
+// walkValidators calls the validators of val and of its nested fields. The validator of val itself is skipped
+// if it implements shadowed, the validator of the struct val is embedded in.
+func walkValidators(ctx context.Context, val reflect.Value, visited map[visit]bool, shadowed reflect.Type) error {
+	if !val.IsValid() || !mayHaveValidators(val.Type()) {
+		return nil
+	}
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Kind() == reflect.Ptr {
+			v := visit{val.Pointer(), val.Type()}
+			if visited[v] {
+				return nil
+			}
+			visited[v] = true
+		}
+		// values pointed to are addressable, so methods with pointer receivers are called there
+		return walkValidators(ctx, val.Elem(), visited, shadowed)
+	case reflect.Struct:
+		typ := val.Type()
+		if val.CanAddr() {
+			typ = reflect.PtrTo(typ)
+		}
+		iface := validatorOf(typ)
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			var shadow reflect.Type
+			if field.Anonymous {
+				shadow = iface
+			}
+			if err := walkValidators(ctx, val.Field(i), visited, shadow); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if err := walkValidators(ctx, val.Index(i), visited, nil); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if err := walkValidators(ctx, iter.Value(), visited, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if val.CanAddr() {
+		val = val.Addr()
+	}
+	if !val.CanInterface() {
+		return nil
+	}
+	iface := validatorOf(val.Type())
+	if iface == nil || iface == shadowed {
+		return nil
+	}
+	switch v := val.Interface().(type) {
+	case ContextValidator:
+		return v.ValidateContext(ctx)
+	case Validator:
+		return v.Validate()
+	}
+	return nil
+}
+
+var (
+	validatorType        = reflect.TypeOf((*Validator)(nil)).Elem()
+	contextValidatorType = reflect.TypeOf((*ContextValidator)(nil)).Elem()
+	validatorsCache      sync.Map // map[reflect.Type]bool
+)
+
+// validatorOf returns the interface of the validator called on values of typ,
+// which is ContextValidator, Validator, or nil if typ implements neither.
+func validatorOf(typ reflect.Type) reflect.Type {
+	switch {
+	case typ.Implements(contextValidatorType):
+		return contextValidatorType
+	case typ.Implements(validatorType):
+		return validatorType
+	}
+	return nil
+}
+
+// mayHaveValidators reports whether values of typ, or any value they contain, may implement Validator or
+// ContextValidator, so that values which cannot are not walked. The result is cached per type.
+func mayHaveValidators(typ reflect.Type) bool {
+	if has, ok := validatorsCache.Load(typ); ok {
+		return has.(bool)
+	}
+	has := reachesValidators(typ, make(map[reflect.Type]bool))
+	validatorsCache.Store(typ, has)
+	return has
+}
+
+func reachesValidators(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+	if validatorOf(typ) != nil || validatorOf(reflect.PtrTo(typ)) != nil {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Interface:
+		// the dynamic value may implement them
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return reachesValidators(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.IsExported() && reachesValidators(field.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateStruct validates v, a struct or a pointer to a struct, according to the "validate" tags of its fields.
+// Nested structs, and the elements of slices, arrays and maps, are validated as well.
+//
+// The rules of a tag are separated by commas, e.g. `validate:"required,min=1,max=100"`:
+//   - required: the value must not be zero, nil or empty
+//   - omitempty: the following rules are skipped if the value is zero, nil or empty
+//   - min=n, max=n: the minimum and maximum of a number, or of the length of a string, slice, array or map
+//   - len=n: the exact length of a string, slice, array or map, or the exact value of a number
+//   - oneof=a b c: the value must be one of the space-separated values
+//   - email, url, uuid: the string must be an email address, an absolute URL or a UUID
+//   - regexp=pattern: the string must match the pattern, which may contain commas so it must be the last rule
+//
+// All failures are returned as BindingErrors with status code 422, where Field of each BindingError is the path
+// of the field in dot and bracket notation, e.g. "items[0].qty", named after its "json", "form" or "query" tag.
+// Invalid tags are reported as an ordinary error, and values other than structs are not validated.
+func ValidateStruct(v any) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+	vd := &validator{visited: make(map[visit]bool)}
+	if err := vd.validateStruct(val, ""); err != nil {
+		return err
+	}
+	if len(vd.errs) > 0 {
+		return vd.errs
+	}
+	return nil
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type validator struct {
+	errs    BindingErrors
+	visited map[visit]bool
+}
+
+type validationRule struct {
+	name, param string
+}
+
+func (vd *validator) validateStruct(val reflect.Value, path string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		fieldPath := path
+		if ft := field.Type; !field.Anonymous || (ft.Kind() != reflect.Struct && (ft.Kind() != reflect.Ptr || ft.Elem().Kind() != reflect.Struct)) {
+			// fields of embedded structs are promoted
+			fieldPath = joinFieldPath(path, fieldName(field))
+		}
+		fieldVal := val.Field(i)
+		if tag != "" {
+			if err := vd.validateRules(fieldVal, fieldPath, parseValidationRules(tag)); err != nil {
+				return err
+			}
+		}
+		if err := vd.validateValue(fieldVal, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateValue validates the nested structs of val.
+func (vd *validator) validateValue(val reflect.Value, path string) error {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Kind() == reflect.Ptr {
+			// pointers may be cyclic
+			v := visit{val.Pointer(), val.Type()}
+			if vd.visited[v] {
+				return nil
+			}
+			vd.visited[v] = true
+		}
+		return vd.validateValue(val.Elem(), path)
+	case reflect.Struct:
+		return vd.validateStruct(val, path)
+	case reflect.Slice, reflect.Array:
+		if !mayContainStruct(val.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := vd.validateValue(val.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !mayContainStruct(val.Type().Elem()) {
+			return nil
+		}
+		keys := val.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			if err := vd.validateValue(val.MapIndex(key), fmt.Sprintf("%s[%v]", path, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateRules validates val against rules, and records the first failure only.
+func (vd *validator) validateRules(val reflect.Value, path string, rules []validationRule) error {
+	// pointers are validated by the values they point to
+	for (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && !val.IsNil() {
+		val = val.Elem()
+	}
+	empty := isEmptyValue(val)
+	for _, rule := range rules {
+		var message string
+		switch rule.name {
+		case "required":
+			if empty {
+				message = "is required"
+			}
+		case "omitempty":
+			if empty {
+				return nil
+			}
+		default:
+			if (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil() {
+				continue
+			}
+			var err error
+			if message, err = checkValidationRule(val, rule); err != nil {
+				return fmt.Errorf("invalid validate tag of field %s: %w", path, err)
+			}
+		}
+		if message != "" {
+			vd.errs = append(vd.errs, newValidationError(path, message))
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkValidationRule returns the failure message of val against rule, or an empty string if val passes.
+func checkValidationRule(val reflect.Value, rule validationRule) (string, error) {
+	switch rule.name {
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s parameter %q", rule.name, rule.param)
+		}
+		size, isLen, ok := measureValue(val)
+		if !ok {
+			return "", fmt.Errorf("rule %s is not applicable to %s", rule.name, val.Type())
+		}
+		subject := "must be"
+		if isLen {
+			subject = "length must be"
+		}
+		switch {
+		case rule.name == "min" && size < n:
+			return fmt.Sprintf("%s at least %s", subject, rule.param), nil
+		case rule.name == "max" && size > n:
+			return fmt.Sprintf("%s at most %s", subject, rule.param), nil
+		case rule.name == "len" && size != n:
+			return fmt.Sprintf("%s %s", subject, rule.param), nil
+		}
+	case "oneof":
+		s, ok := formatValue(val)
+		if !ok {
+			return "", fmt.Errorf("rule %s is not applicable to %s", rule.name, val.Type())
+		}
+		for _, option := range strings.Fields(rule.param) {
+			if s == option {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", rule.param), nil
+	case "email", "url", "uuid", "regexp":
+		if val.Kind() != reflect.String {
+			return "", fmt.Errorf("rule %s is not applicable to %s", rule.name, val.Type())
+		}
+		s := val.String()
+		switch rule.name {
+		case "email":
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return "must be a valid email address", nil
+			}
+		case "url":
+			if u, err := url.Parse(s); err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+				return "must be a valid URL", nil
+			}
+		case "uuid":
+			if !uuidPattern.MatchString(s) {
+				return "must be a valid UUID", nil
+			}
+		case "regexp":
+			re, err := compileRegexp(rule.param)
+			if err != nil {
+				return "", err
+			}
+			if !re.MatchString(s) {
+				return fmt.Sprintf("must match %s", rule.param), nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule.name)
+	}
+	return "", nil
+}
+
+// parseValidationRules parses the rules of a "validate" tag.
+func parseValidationRules(tag string) []validationRule {
+	var rules []validationRule
+	for tag != "" {
+		var r string
+		if strings.HasPrefix(tag, "regexp=") {
+			r, tag = tag, ""
+		} else {
+			r, tag, _ = strings.Cut(tag, ",")
+		}
+		name, param, _ := strings.Cut(r, "=")
+		rules = append(rules, validationRule{name: strings.TrimSpace(name), param: param})
+	}
+	return rules
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexpsCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexpsCache.Store(pattern, re)
+	return re, nil
+}
+
+func newValidationError(field, message string) *BindingError {
+	return &BindingError{
+		Field:     field,
+		HTTPError: NewHTTPError(http.StatusUnprocessableEntity, message),
+	}
+}
+
+// measureValue returns the length of a string, slice, array or map, or the value of a number.
+func measureValue(val reflect.Value) (size float64, isLen bool, ok bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(val.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(val.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(val.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false, true
+	}
+	return 0, false, false
+}
+
+// formatValue formats a string or a number.
+func formatValue(val reflect.Value) (string, bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(val.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'g', -1, val.Type().Bits()), true
+	}
+	return "", false
+}
+
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return val.IsZero()
+}
+
+func mayContainStruct(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// fieldName returns the name of a field in its "json", "form" or "query" tag, or else the name of the field itself.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query"} {
+		if name, _ := parseTag(field.Tag.Get(tag)); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
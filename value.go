@@ -0,0 +1,93 @@
+package httpx
+
+import (
+	"context"
+	"sync"
+)
+
+var storeKey = contextKey("store")
+
+// valueStore is the mutable key/value map of a request, shared by every http.Request derived from it.
+type valueStore struct {
+	mu     sync.RWMutex
+	values map[any]any
+}
+
+func (s *valueStore) get(key any) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.values[key]
+	return val, ok
+}
+
+func (s *valueStore) set(key, val any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[any]any)
+	}
+	s.values[key] = val
+}
+
+// storeContext is a context.Context that looks up values in its valueStore before its parent.
+type storeContext struct {
+	context.Context
+	store *valueStore
+}
+
+func (c *storeContext) Value(key any) any {
+	if key == storeKey {
+		return c.store
+	}
+	if val, ok := c.store.get(key); ok {
+		return val
+	}
+	return c.Context.Value(key)
+}
+
+// valueStore returns the value store of the request, which is created and stored
+// in the underlying http.Request's context.Context if the context does not have one yet.
+func (r *Request) valueStore() *valueStore {
+	if r.store != nil {
+		return r.store
+	}
+	ctx := r.Request.Context()
+	if s, ok := ctx.Value(storeKey).(*valueStore); ok {
+		r.store = s
+		return s
+	}
+	r.store = new(valueStore)
+	r.Request = r.Request.WithContext(&storeContext{Context: ctx, store: r.store})
+	return r.store
+}
+
+// Key is a typed key of a value set in a request.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new instance of Key. Keys are distinct even if they are created with the same name.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Get returns the value of the key set in req, and false if there is no such value.
+func (k *Key[T]) Get(req *Request) (T, bool) {
+	return k.FromContext(req.Context())
+}
+
+// Set sets the value of the key in req.
+func (k *Key[T]) Set(req *Request, val T) {
+	req.SetValue(k, val)
+}
+
+// FromContext returns the value of the key set in the request of ctx, and false if there is no such value.
+func (k *Key[T]) FromContext(ctx context.Context) (T, bool) {
+	val, ok := ctx.Value(k).(T)
+	return val, ok
+}